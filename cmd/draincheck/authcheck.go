@@ -0,0 +1,97 @@
+package draincheck
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/fhke/kubectl-draincheck/pkg/auth"
+	"github.com/spf13/cobra"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+)
+
+func newAuthCheckCmd(configFlags *genericclioptions.ConfigFlags) *cobra.Command {
+	var (
+		// flags
+		output, namespaceSelector         *string
+		namespaces                        *[]string
+		allNamespaces, offline, listNodes *bool
+		timeout                           *time.Duration
+	)
+
+	cmd := &cobra.Command{
+		Use:   "auth-check",
+		Short: "Check that the current user has the permissions needed to check pods",
+		Long: `Check that the current user has the permissions needed to check pods.
+
+Each permission is checked with a SelfSubjectAccessReview. The same check runs
+automatically before pods are checked, unless --skip-auth-check is used.`,
+		Args: cobra.NoArgs,
+
+		// Validate args
+		PreRun: func(cmd *cobra.Command, args []string) {
+			if len(*namespaces) > 0 && *allNamespaces {
+				log.Panic("cannot specify --namespace and --all-namespaces")
+			}
+			if *output != OutputYAML && *output != OutputJSON && *output != OutputText {
+				log.Panicf("Unexpected output format %s. Valid values are %s, %s or %s", *output, OutputJSON, OutputYAML, OutputText)
+			}
+		},
+
+		Run: func(cmd *cobra.Command, args []string) {
+			// exit with non-zero code after other deferred calls have run
+			var exitCode int
+			defer func() {
+				if exitCode != 0 {
+					os.Exit(exitCode)
+				}
+			}()
+			defer log.Sync()
+
+			scope := auth.Scope{
+				Evict:          !*offline,
+				DrainFilters:   true,
+				ListNodes:      *listNodes,
+				ListNamespaces: *namespaceSelector != "",
+			}
+			if !listsAllNamespaces(*namespaces, *allNamespaces) {
+				scope.Namespace = mustResolveNamespace(literalNamespace(*namespaces), configFlags)
+			}
+
+			ctx, can := context.WithTimeout(context.Background(), *timeout)
+			defer can()
+			res, err := auth.Check(ctx, mustNewClientset(configFlags), auth.Requirements(scope))
+			if err != nil {
+				log.Panicw("Error checking permissions", "error", err)
+			}
+			if len(res.Missing()) > 0 {
+				exitCode = ExitCodeMissingPermissions
+			}
+
+			// Write data in preferred format
+			switch *output {
+			case OutputText:
+				fmt.Print(string(res.Table()))
+			case OutputYAML:
+				mustMarshalWrite(log, res.YAML)
+			case OutputJSON:
+				mustMarshalWrite(log, res.JSON)
+			default:
+				// We should never get here, as invalid options should be
+				// picked up in PreRun
+				log.Panicw("Internal error: no formatter found", "output", *output)
+			}
+		},
+	}
+
+	namespaces = cmd.Flags().StringArrayP("namespace", "n", nil, "Namespace, or glob pattern matching namespaces, that pods will be checked in. May be specified multiple times. Defaults to the namespace of the kubeconfig context")
+	namespaceSelector = cmd.Flags().String("namespace-selector", "", "Check permissions needed for --namespace-selector, which lists namespaces")
+	allNamespaces = cmd.Flags().BoolP("all-namespaces", "A", false, "Check permissions needed to check pods in all namespaces, or on nodes")
+	offline = cmd.Flags().Bool("offline", false, "Check permissions needed for --offline, which doesn't create evictions")
+	listNodes = cmd.Flags().Bool("list-nodes", false, "Check permissions needed for --node-selector, which lists nodes")
+	timeout = cmd.Flags().DurationP("api-timeout", "T", time.Second*30, "Timeout for calls to Kubernetes API server")
+	output = cmd.Flags().StringP("output", "o", OutputText, "Output format - yaml, json or text")
+
+	return cmd
+}
@@ -0,0 +1,101 @@
+package draincheck
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/fhke/kubectl-draincheck/pkg/checker"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/client-go/kubernetes"
+)
+
+// A cluster to check
+type cluster struct {
+	name      string // kubeconfig context, empty for the current context
+	clientset kubernetes.Interface
+	namespace string // namespace from flags, or the namespace of the kubeconfig context
+}
+
+// Load clusters for kubeconfig contexts, or the current context if no contexts are given.
+// Other config flags, e.g. --as, apply to every context.
+func mustLoadClusters(cf *genericclioptions.ConfigFlags, contexts []string, allContexts bool, namespace string) []cluster {
+	if allContexts {
+		raw, err := cf.ToRawKubeConfigLoader().RawConfig()
+		if err != nil {
+			log.Panicw("Error loading kubeconfig", "error", err)
+		}
+		contexts = make([]string, 0, len(raw.Contexts))
+		for name := range raw.Contexts {
+			contexts = append(contexts, name)
+		}
+		sort.Strings(contexts)
+	}
+
+	if len(contexts) == 0 {
+		return []cluster{{
+			clientset: mustNewClientset(cf),
+			namespace: mustResolveNamespace(namespace, cf),
+		}}
+	}
+
+	clusters := make([]cluster, len(contexts))
+	for i, name := range contexts {
+		// config flags are not persistent, so the kubeconfig is loaded again for each context
+		*cf.Context = name
+		clusters[i] = cluster{
+			name:      name,
+			clientset: mustNewClientset(cf),
+			namespace: mustResolveNamespace(namespace, cf),
+		}
+	}
+
+	return clusters
+}
+
+// Check clusters concurrently, merging results in the order of clusters. When checking
+// multiple clusters, a cluster that can't be checked doesn't stop other clusters being
+// checked, & an IncompleteError is returned.
+func checkClusters(clusters []cluster, check func(cluster) (checker.Results, error)) (checker.Results, error) {
+	if len(clusters) == 1 {
+		return check(clusters[0])
+	}
+
+	var (
+		results = make([]checker.Results, len(clusters))
+		errs    = make([][]error, len(clusters))
+		wg      sync.WaitGroup
+	)
+
+	for i := range clusters {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			res, err := check(clusters[i])
+			if ie := (*checker.IncompleteError)(nil); errors.As(err, &ie) {
+				errs[i] = ie.Errors
+			} else if err != nil {
+				// report the cluster as incomplete, rather than failing every cluster
+				errs[i] = []error{fmt.Errorf("error checking cluster %s: %w", clusters[i].name, err)}
+			}
+			results[i] = res
+		}(i)
+	}
+	wg.Wait()
+
+	var (
+		merged checker.Results
+		all    []error
+	)
+	for i := range clusters {
+		merged = append(merged, results[i]...)
+		all = append(all, errs[i]...)
+	}
+	if len(all) > 0 {
+		return merged, &checker.IncompleteError{Errors: all}
+	}
+
+	return merged, nil
+}
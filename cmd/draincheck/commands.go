@@ -2,93 +2,274 @@ package draincheck
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"os"
+	"sync"
 	"time"
 
+	"github.com/fhke/kubectl-draincheck/pkg/auth"
 	"github.com/fhke/kubectl-draincheck/pkg/checker"
+	"github.com/fhke/kubectl-draincheck/pkg/retry"
 	"github.com/spf13/cobra"
 	"go.uber.org/zap"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
 )
 
 var log *zap.SugaredLogger = mustNewLogger()
 
 const (
-	OutputYAML = "yaml"
-	OutputJSON = "json"
-	OutputText = "text"
+	// Exit code when results are written, but some pods could not be checked
+	ExitCodeIncomplete = 3
+	// Exit code when the current user is missing permissions needed to check pods
+	ExitCodeMissingPermissions = 4
+)
+
+// Error checking a cluster that the current user is missing permissions for
+var errMissingPermissions = errors.New("current user is missing permissions needed to check pods")
+
+const (
+	OutputYAML   = "yaml"
+	OutputJSON   = "json"
+	OutputText   = "text"
+	OutputNDJSON = "ndjson"
 )
 
 func NewCmd() *cobra.Command {
 	var (
 		// flags
-		namespace, kubeconfig, output *string
-		allNamespaces                 *bool
-		timeout                       *time.Duration
-		workers                       *uint
+		output, nodeSelector    *string
+		selector, fieldSelector *string
+		namespaceSelector       *string
+		namespaces, excluded    *[]string
+		allNamespaces, simulate *bool
+		offline, skipAuthCheck  *bool
+		drainOptions            = &checker.DrainOptions{}
+		backoff                 = retry.DefaultBackoff
+		timeout                 *time.Duration
+		workers                 *uint
+		pageSize                *int64
+		nodes, contexts         *[]string
+		allContexts, showAll    *bool
+		configPath, profile     *string
+		configFlags             *genericclioptions.ConfigFlags
+
+		// whether namespace filters were set on the command line, rather than
+		// from the environment or config file
+		namespaceFiltersSet bool
 	)
 
 	cmd := &cobra.Command{
-		Use:   "kubectl draincheck [POD ...]",
+		Use:   "draincheck [POD ...]",
 		Short: "Check whether pods can be evicted by kubectl drain",
+		Args:  cobra.ArbitraryArgs,
+
+		// Set flags from the environment & config file, for all subcommands
+		PersistentPreRun: func(cmd *cobra.Command, args []string) {
+			namespaceFiltersSet = cmd.Flags().Changed("exclude-namespace") || cmd.Flags().Changed("namespace-selector")
+			mustApplyConfig(cmd, *configPath, *profile)
+		},
 
 		// Validate args
 		PreRun: func(cmd *cobra.Command, args []string) {
 			if len(args) > 0 && *allNamespaces {
 				log.Panic("cannot specify --all-namespaces and specific pods")
 			}
-			if *output != OutputYAML && *output != OutputJSON && *output != OutputText {
-				log.Panicf("Unexpected output format %s. Valid values are %s, %s or %s", *output, OutputJSON, OutputYAML, OutputText)
+			if len(args) > 0 && (len(*nodes) > 0 || *nodeSelector != "") {
+				log.Panic("cannot specify --node or --node-selector and specific pods")
+			}
+			if len(args) > 0 && (*selector != "" || *fieldSelector != "") {
+				log.Panic("cannot specify --selector or --field-selector and specific pods")
+			}
+			if len(args) > 0 && namespaceFiltersSet {
+				log.Panic("cannot specify --exclude-namespace or --namespace-selector and specific pods")
+			}
+			if len(args) > 0 && len(*namespaces) > 0 && literalNamespace(*namespaces) == "" {
+				log.Panic("specific pods can only be checked in a single namespace, without glob patterns")
+			}
+			if len(*namespaces) > 0 && *allNamespaces {
+				log.Panic("cannot specify --namespace and --all-namespaces")
+			}
+			if *simulate && len(*nodes) == 0 && *nodeSelector == "" {
+				log.Panic("--simulate can only be used with --node or --node-selector")
+			}
+			if len(*nodes) > 0 && *nodeSelector != "" {
+				log.Panic("cannot specify --node and --node-selector")
+			}
+			if *workers == 0 {
+				log.Panic("--workers must be at least 1")
+			}
+			if len(*contexts) > 0 && *allContexts {
+				log.Panic("cannot specify --contexts and --all-contexts")
+			}
+			if (len(*contexts) > 0 || *allContexts) && *configFlags.Context != "" {
+				log.Panic("cannot specify --context with --contexts or --all-contexts")
+			}
+			if *output != OutputYAML && *output != OutputJSON && *output != OutputText && *output != OutputNDJSON {
+				log.Panicf("Unexpected output format %s. Valid values are %s, %s, %s or %s", *output, OutputJSON, OutputYAML, OutputText, OutputNDJSON)
 			}
 		},
 
 		Run: func(cmd *cobra.Command, pods []string) {
+			// exit with non-zero code after other deferred calls have run
+			var exitCode int
+			defer func() {
+				if exitCode != 0 {
+					os.Exit(exitCode)
+				}
+			}()
 			defer log.Sync()
 
-			// create clientset
-			cs, err := newClientset(getKubeconfigPath(*kubeconfig))
-			if err != nil {
-				log.Panicw("Error getting clientset", "error", err)
-			}
-
 			// create parent context
 			ctx := context.Background()
 
-			// create eviction checker
-			ctx2, can := context.WithTimeout(ctx, *timeout)
-			ch, err := checker.NewChecker(ctx2, cs)
-			if err != nil {
-				can()
-				log.Panicw("Error creating checker", "error", err)
-			}
-			defer ch.Stop()
-			can()
+			// load clusters to check, defaulting to the namespace of each kubeconfig context
+			multiCluster := len(*contexts) > 0 || *allContexts
+			clusters := mustLoadClusters(configFlags, *contexts, *allContexts, literalNamespace(*namespaces))
 
-			var res checker.Results
+			// check the given or kubeconfig namespace only, unless checking all namespaces,
+			// namespaces matching patterns or nodes. Namespace filters apply within the scope.
+			nodeMode := len(*nodes) > 0 || *nodeSelector != ""
+			scopeNamespace := func(cl cluster) string {
+				if listsAllNamespaces(*namespaces, *allNamespaces) || nodeMode {
+					return ""
+				}
+				return cl.namespace
+			}
+			// pods checked by name aren't filtered, so that namespace filters from the config
+			// file don't stop pods being checked by name
+			filterNamespaces := len(pods) == 0
 
-			if len(pods) > 0 {
-				// check by name
-				res, err = ch.PodsByName(ctx, *timeout, *namespace, *workers, pods...)
-			} else {
-				// check all in namespace/cluster
-				var ns string
-				if !*allNamespaces {
-					ns = *namespace
+			// Check permissions before starting informers, which hang on missing permissions.
+			// Clusters that the current user is missing permissions for aren't checked, but
+			// other clusters still are.
+			denied := make(map[string]bool)
+			if !*skipAuthCheck {
+				for _, cl := range clusters {
+					if !preflight(ctx, cl.clientset, *timeout, cl.name, auth.Scope{
+						Namespace:      scopeNamespace(cl),
+						Evict:          !*offline && !drainOptions.DisableEviction,
+						DrainFilters:   true,
+						ListNodes:      *nodeSelector != "",
+						ListNamespaces: filterNamespaces && *namespaceSelector != "",
+					}) {
+						denied[cl.name] = true
+					}
+				}
+				if len(denied) == len(clusters) {
+					exitCode = ExitCodeMissingPermissions
+					return
 				}
-				res, err = ch.AllPods(ctx, ns, *timeout, *workers)
 			}
 
-			if err != nil {
+			// options for creating eviction checkers
+			checkerOpts := []checker.Option{
+				checker.WithDrainOptions(*drainOptions),
+				checker.WithRetry(backoff),
+				checker.WithPageSize(*pageSize),
+			}
+			if *offline {
+				checkerOpts = append(checkerOpts, checker.WithOfflineEvaluation())
+			}
+
+			// options for selecting pods
+			checkOpts := []checker.CheckOption{
+				checker.WithLabelSelector(*selector),
+				checker.WithFieldSelector(*fieldSelector),
+			}
+			if filterNamespaces {
+				// filter namespaces before any dry-runs
+				checkOpts = append(checkOpts,
+					checker.WithNamespaces(*namespaces...),
+					checker.WithExcludedNamespaces(*excluded...),
+					checker.WithNamespaceSelector(*namespaceSelector),
+				)
+			}
+			if *simulate {
+				checkOpts = append(checkOpts, checker.WithBudgetSimulation())
+			}
+			if *showAll {
+				checkOpts = append(checkOpts, checker.WithAllResults())
+			}
+			if *output == OutputNDJSON {
+				// write each result as soon as it is ready. Clusters are checked concurrently.
+				var mu sync.Mutex
+				checkOpts = append(checkOpts, checker.OnResult(func(r checker.Result) {
+					mu.Lock()
+					defer mu.Unlock()
+					mustMarshalWrite(log, r.NDJSON)
+				}))
+			}
+
+			res, err := checkClusters(clusters, func(cl cluster) (checker.Results, error) {
+				if denied[cl.name] {
+					// reported as incomplete, as some other cluster can be checked
+					return nil, errMissingPermissions
+				}
+
+				// copy options, as clusters are checked concurrently
+				opts := append([]checker.Option{}, checkerOpts...)
+				if multiCluster {
+					opts = append(opts, checker.WithCluster(cl.name))
+				}
+				if ns := scopeNamespace(cl); ns != "" {
+					// only watch the namespace being checked, so that namespace-scoped permissions are enough
+					opts = append(opts, checker.WithNamespace(ns))
+				}
+
+				// create eviction checker
+				ctx2, can := context.WithTimeout(ctx, *timeout)
+				defer can()
+				ch, err := checker.NewChecker(ctx2, cl.clientset, opts...)
+				if err != nil {
+					return nil, fmt.Errorf("error creating checker: %w", err)
+				}
+				defer ch.Stop()
+
+				if len(pods) > 0 {
+					// check by name
+					return ch.PodsByName(ctx, *timeout, cl.namespace, *workers, pods, checkOpts...)
+				} else if len(*nodes) > 0 {
+					// check all pods on nodes
+					return ch.Nodes(ctx, *nodes, *timeout, *workers, checkOpts...)
+				} else if *nodeSelector != "" {
+					// check all pods on nodes matching selector
+					return ch.NodesBySelector(ctx, *nodeSelector, *timeout, *workers, checkOpts...)
+				}
+
+				// check all in namespace/cluster
+				return ch.AllPods(ctx, scopeNamespace(cl), *timeout, *workers, checkOpts...)
+			})
+
+			// Some pods could not be checked. Results are still written,
+			// but the exit code reflects that they are incomplete.
+			if ie := (*checker.IncompleteError)(nil); errors.As(err, &ie) {
+				log.Warnw("Some pods could not be checked", "error", err)
+				exitCode = ExitCodeIncomplete
+			} else if err != nil {
 				log.Panicw("Error checking eligibility of pods for eviction", "error", err)
 			}
 
 			// Write data in preferred format
 			switch *output {
 			case OutputText:
-				fmt.Print(string(res.Table()))
+				var tableOpts []checker.TableOption
+				if len(*nodes) > 0 || *nodeSelector != "" {
+					tableOpts = append(tableOpts, checker.WithNodeColumn())
+				}
+				if multiCluster {
+					tableOpts = append(tableOpts, checker.WithClusterColumn())
+				}
+				if *showAll {
+					tableOpts = append(tableOpts, checker.WithStatusColumn())
+				}
+				fmt.Print(string(res.Table(tableOpts...)))
 			case OutputYAML:
 				mustMarshalWrite(log, res.YAML)
 			case OutputJSON:
 				mustMarshalWrite(log, res.JSON)
+			case OutputNDJSON:
+				// results have already been written
 			default:
 				// We should never get here, as invalid options should be
 				// picked up in PreRun
@@ -97,12 +278,38 @@ func NewCmd() *cobra.Command {
 		},
 	}
 
-	kubeconfig = cmd.PersistentFlags().String("kubeconfig", defaultKubeConfig(), "Path to kubeconfig")
-	namespace = cmd.Flags().StringP("namespace", "n", "default", "Namespace")
+	configFlags = newConfigFlags(cmd.PersistentFlags())
+	configPath = cmd.PersistentFlags().String("config", "", "Path to config file holding default flag values & profiles. Defaults to ~/.config/draincheck/config.yaml")
+	profile = cmd.PersistentFlags().String("profile", "", "Name of profile in config file to apply")
+	skipAuthCheck = cmd.PersistentFlags().Bool("skip-auth-check", false, "Don't check that the current user has the permissions needed before checking pods")
+	namespaces = cmd.Flags().StringArrayP("namespace", "n", nil, "Namespace, or glob pattern matching namespaces, e.g. team-*. May be specified multiple times. Defaults to the namespace of the kubeconfig context")
+	excluded = cmd.Flags().StringArray("exclude-namespace", nil, "Don't check pods in namespaces matching glob pattern, e.g. kube-*. May be specified multiple times")
+	namespaceSelector = cmd.Flags().String("namespace-selector", "", "Only check pods in namespaces matching label selector, e.g. tier=prod")
 	allNamespaces = cmd.Flags().BoolP("all-namespaces", "A", false, "Check pods in all namespaces")
-	timeout = cmd.Flags().DurationP("api-timeout", "T", time.Second*30, "Timeout for calls to Kubernetes API server")
-	output = cmd.Flags().StringP("output", "o", OutputText, "Output format - yaml, json or text")
+	timeout = cmd.Flags().DurationP("api-timeout", "T", time.Second*30, "Timeout for calls to Kubernetes API server, including retries")
+	output = cmd.Flags().StringP("output", "o", OutputText, "Output format - yaml, json, ndjson or text. ndjson writes each result as soon as it is ready")
 	workers = cmd.Flags().UintP("workers", "W", 10, "Number of worker goroutines to run")
+	pageSize = cmd.Flags().Int64("page-size", checker.DefaultPageSize, "Number of pods to request from Kubernetes API server per page when listing pods")
+	nodes = cmd.Flags().StringArray("node", nil, "Check pods on node in all namespaces, as kubectl drain would. May be specified multiple times")
+	nodeSelector = cmd.Flags().String("node-selector", "", "Check pods on all nodes matching label selector, grouped by node")
+	simulate = cmd.Flags().Bool("simulate", false, "Simulate draining all pods on each node together, reporting pods that would be blocked once earlier evictions use up a shared disruption budget")
+	showAll = cmd.Flags().Bool("show-all", false, "Show every selected pod with its status - Evictable, Unevictable, Skipped or Error - and pod disruption budgets, not only pods that cannot be evicted")
+	contexts = cmd.Flags().StringSlice("contexts", nil, "Check clusters for kubeconfig contexts concurrently, merging results with a cluster column")
+	allContexts = cmd.Flags().Bool("all-contexts", false, "Check clusters for all kubeconfig contexts concurrently, merging results with a cluster column")
+	cmd.Flags().BoolVar(&drainOptions.IgnoreDaemonSets, "ignore-daemonsets", false, "Skip pods managed by daemonsets, as kubectl drain --ignore-daemonsets would")
+	cmd.Flags().BoolVar(&drainOptions.DeleteEmptyDirData, "delete-emptydir-data", false, "Allow pods using emptyDir volumes, as kubectl drain --delete-emptydir-data would")
+	cmd.Flags().BoolVar(&drainOptions.Force, "force", false, "Allow pods that are not managed by a controller, as kubectl drain --force would")
+	cmd.Flags().BoolVar(&drainOptions.DisableEviction, "disable-eviction", false, "Delete pods without using the eviction API, as kubectl drain --disable-eviction would")
+	offline = cmd.Flags().Bool("offline", false, "Evaluate pod disruption budgets instead of running eviction dry-runs, so that no permission to create evictions is needed")
+	cmd.Flags().UintVar(&backoff.Retries, "retries", backoff.Retries, "Number of times to retry calls to Kubernetes API server that fail with transient errors")
+	cmd.Flags().DurationVar(&backoff.Initial, "retry-backoff", backoff.Initial, "Delay before the first retry, doubling for each retry after. Longer delays requested by the API server are respected")
+	cmd.Flags().DurationVar(&backoff.Max, "retry-max-backoff", backoff.Max, "Maximum delay between retries")
+	selector = cmd.Flags().StringP("selector", "l", "", "Only check pods matching label selector")
+	fieldSelector = cmd.Flags().String("field-selector", "", "Only check pods matching field selector, e.g. status.phase=Running")
+
+	cmd.AddCommand(newPlanCmd(configFlags, skipAuthCheck))
+	cmd.AddCommand(newAuthCheckCmd(configFlags))
+	cmd.AddCommand(newManifestsCmd())
 
 	return cmd
 }
@@ -0,0 +1,74 @@
+package draincheck
+
+import (
+	"os"
+
+	"github.com/fhke/kubectl-draincheck/pkg/config"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// Set flags that were not set on the command line from DRAINCHECK_* environment
+// variables, then the profile & defaults in the config file
+func mustApplyConfig(cmd *cobra.Command, path, profile string) {
+	// the config file & profile can also be set from the environment
+	if path == "" {
+		path = os.Getenv(config.EnvName("config"))
+	}
+	if profile == "" {
+		profile = os.Getenv(config.EnvName("profile"))
+	}
+
+	// the default config file is optional, so treat a missing default path (e.g.
+	// no home directory) like a missing file
+	optional := path == ""
+	if optional {
+		var err error
+		if path, err = config.DefaultPath(); err != nil {
+			log.Debugw("Could not get default config file path, skipping config file", "error", err)
+		}
+	}
+
+	f := &config.File{}
+	if path != "" {
+		var err error
+		if f, err = config.Load(path, optional); err != nil {
+			log.Panicw("Error loading config file", "error", err)
+		}
+	}
+
+	// catch typos in the config file
+	known := flagNames(cmd.Root())
+	for _, key := range f.Keys() {
+		if !known[key] {
+			log.Panicw("Unknown flag in config file", "path", path, "flag", key)
+		}
+	}
+
+	values, err := f.Profile(profile)
+	if err != nil {
+		log.Panicw("Error loading profile from config file", "path", path, "error", err)
+	}
+
+	if err := config.Apply(cmd.Flags(), values, os.LookupEnv); err != nil {
+		log.Panicw("Error applying config", "error", err)
+	}
+}
+
+// Get the names of flags for a command & all subcommands
+func flagNames(cmd *cobra.Command) map[string]bool {
+	names := make(map[string]bool)
+
+	visit := func(flag *pflag.Flag) {
+		names[flag.Name] = true
+	}
+	cmd.Flags().VisitAll(visit)
+	cmd.PersistentFlags().VisitAll(visit)
+	for _, sub := range cmd.Commands() {
+		for name := range flagNames(sub) {
+			names[name] = true
+		}
+	}
+
+	return names
+}
@@ -1,13 +1,19 @@
 package draincheck
 
 import (
+	"context"
 	"fmt"
 	"os"
-	"path"
+	"strings"
+	"time"
 
+	"github.com/fhke/kubectl-draincheck/pkg/auth"
+	"github.com/fhke/kubectl-draincheck/pkg/checker"
+	"github.com/spf13/pflag"
 	"go.uber.org/zap"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
 	"k8s.io/client-go/kubernetes"
-	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/rest"
 )
 
 func mustNewLogger() *zap.SugaredLogger {
@@ -19,35 +25,117 @@ func mustNewLogger() *zap.SugaredLogger {
 	return unsugared.Sugar()
 }
 
-func defaultKubeConfig() string {
-	homeDir, err := os.UserHomeDir()
+// Create a clientset from the kubectl config flags. These use the standard kubeconfig
+// loading rules, loading --kubeconfig, $KUBECONFIG (which may be a list of files) or
+// ~/.kube/config, and falling back to in-cluster config when running in a pod.
+func newClientset(getter genericclioptions.RESTClientGetter) (kubernetes.Interface, error) {
+	config, err := getter.ToRESTConfig()
 	if err != nil {
-		panic("Could not get user home directory: " + err.Error())
+		return nil, err
+	}
+
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, err
 	}
 
-	return path.Join(homeDir, ".kube", "config")
+	return clientset, nil
 }
 
-func getKubeconfigPath(kubeconfig string) string {
-	if k := os.Getenv("KUBECONFIG"); k != "" {
-		return k
-	} else {
-		return kubeconfig
+func mustNewClientset(getter genericclioptions.RESTClientGetter) kubernetes.Interface {
+	cs, err := newClientset(getter)
+	if err != nil {
+		log.Panicw("Error getting clientset", "error", err)
 	}
+
+	return cs
 }
 
-func newClientset(kubeconfig string) (kubernetes.Interface, error) {
-	config, err := clientcmd.BuildConfigFromFlags("", kubeconfig)
+func mustNewChecker(ctx context.Context, cs kubernetes.Interface, timeout time.Duration, opts ...checker.Option) *checker.Checker {
+	// create eviction checker
+	ctx2, can := context.WithTimeout(ctx, timeout)
+	defer can()
+	ch, err := checker.NewChecker(ctx2, cs, opts...)
 	if err != nil {
-		return nil, err
+		log.Panicw("Error creating checker", "error", err)
 	}
 
-	clientset, err := kubernetes.NewForConfig(config)
+	return ch
+}
+
+// Create kubectl config flags, e.g. --kubeconfig, --context & --as, with flags to
+// set the client rate limits
+func newConfigFlags(flags *pflag.FlagSet) *genericclioptions.ConfigFlags {
+	// not persistent, so that the kubeconfig can be loaded for each context with --contexts
+	cf := genericclioptions.NewConfigFlags(false)
+	// draincheck defines its own --namespace flag
+	cf.Namespace = nil
+	cf.AddFlags(flags)
+
+	qps := flags.Float32("qps", rest.DefaultQPS, "Maximum queries per second to Kubernetes API server")
+	burst := flags.Int("burst", rest.DefaultBurst, "Maximum burst of queries to Kubernetes API server")
+
+	return cf.WithWrapConfigFn(func(c *rest.Config) *rest.Config {
+		c.QPS = *qps
+		c.Burst = *burst
+		return c
+	})
+}
+
+// Get the namespace if set, otherwise the namespace of the kubeconfig context
+func mustResolveNamespace(namespace string, getter genericclioptions.RESTClientGetter) string {
+	if namespace != "" {
+		return namespace
+	}
+
+	namespace, _, err := getter.ToRawKubeConfigLoader().Namespace()
 	if err != nil {
-		return nil, err
+		log.Panicw("Error getting namespace from kubeconfig", "error", err)
 	}
 
-	return clientset, nil
+	return namespace
+}
+
+// Get the namespace if a single namespace without glob characters is given, otherwise ""
+func literalNamespace(namespaces []string) string {
+	if len(namespaces) != 1 || strings.ContainsAny(namespaces[0], `*?[\`) {
+		return ""
+	}
+
+	return namespaces[0]
+}
+
+// Check whether pods must be listed in all namespaces, rather than a single namespace,
+// because all namespaces were requested or namespaces are glob patterns or repeated
+func listsAllNamespaces(namespaces []string, allNamespaces bool) bool {
+	return allNamespaces || len(namespaces) > 0 && literalNamespace(namespaces) == ""
+}
+
+// Check that the current user has the permissions needed for a scope, printing a table
+// of missing permissions to stderr. Returns false if any permissions are missing.
+func preflight(ctx context.Context, cs kubernetes.Interface, timeout time.Duration, cluster string, scope auth.Scope) bool {
+	log := log
+	if cluster != "" {
+		log = log.With("cluster", cluster)
+	}
+
+	ctx2, can := context.WithTimeout(ctx, timeout)
+	defer can()
+
+	res, err := auth.Check(ctx2, cs, auth.Requirements(scope))
+	if err != nil {
+		// don't block checks if access can't be reviewed
+		log.Warnw("Could not check permissions, skipping permission check", "error", err)
+		return true
+	}
+
+	if missing := res.Missing(); len(missing) > 0 {
+		log.Errorw("Missing permissions needed to check pods. Use --skip-auth-check to check anyway", "missing", len(missing))
+		fmt.Fprint(os.Stderr, string(missing.Table()))
+		return false
+	}
+
+	return true
 }
 
 func mustMarshalWrite(log *zap.SugaredLogger, m func() ([]byte, error)) {
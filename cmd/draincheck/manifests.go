@@ -0,0 +1,76 @@
+package draincheck
+
+import (
+	"github.com/fhke/kubectl-draincheck/pkg/manifests"
+	"github.com/spf13/cobra"
+)
+
+func newManifestsCmd() *cobra.Command {
+	var (
+		// flags
+		name, namespace, targetNamespace *string
+		image, schedule, output          *string
+		offline                          *bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "manifests",
+		Short: "Generate manifests for running draincheck as a CronJob",
+		Long: `Generate manifests for running draincheck as a CronJob.
+
+A ServiceAccount, RBAC rules granting exactly the permissions that draincheck
+needs, and a CronJob that checks pods on a schedule are generated. Permissions
+are granted in all namespaces with a ClusterRole, or in a single namespace with
+a Role when --target-namespace is used.`,
+		Args: cobra.NoArgs,
+
+		// Validate args
+		PreRun: func(cmd *cobra.Command, args []string) {
+			if *output != OutputYAML && *output != OutputJSON {
+				log.Panicf("Unexpected output format %s. Valid values are %s or %s", *output, OutputJSON, OutputYAML)
+			}
+		},
+
+		Run: func(cmd *cobra.Command, args []string) {
+			defer log.Sync()
+
+			opts := []manifests.Option{
+				manifests.WithName(*name),
+				manifests.WithNamespace(*namespace),
+				manifests.WithTargetNamespace(*targetNamespace),
+				manifests.WithImage(*image),
+				manifests.WithSchedule(*schedule),
+			}
+			if *offline {
+				opts = append(opts, manifests.WithOffline())
+			}
+
+			objs, err := manifests.Generate(opts...)
+			if err != nil {
+				log.Panicw("Error generating manifests", "error", err)
+			}
+
+			// Write data in preferred format
+			switch *output {
+			case OutputYAML:
+				mustMarshalWrite(log, func() ([]byte, error) { return manifests.YAML(objs) })
+			case OutputJSON:
+				mustMarshalWrite(log, func() ([]byte, error) { return manifests.JSON(objs) })
+			default:
+				// We should never get here, as invalid options should be
+				// picked up in PreRun
+				log.Panicw("Internal error: no formatter found", "output", *output)
+			}
+		},
+	}
+
+	name = cmd.Flags().String("name", manifests.DefaultName, "Name of generated resources")
+	namespace = cmd.Flags().StringP("namespace", "n", manifests.DefaultNamespace, "Namespace to create the ServiceAccount & CronJob in")
+	targetNamespace = cmd.Flags().String("target-namespace", "", "Only check pods in namespace, granting permissions with a Role. Pods in all namespaces are checked if not set")
+	image = cmd.Flags().String("image", manifests.DefaultImage, "draincheck image")
+	schedule = cmd.Flags().String("schedule", manifests.DefaultSchedule, "CronJob schedule")
+	offline = cmd.Flags().Bool("offline", false, "Evaluate pod disruption budgets offline, without permission to create evictions")
+	output = cmd.Flags().StringP("output", "o", OutputYAML, "Output format - yaml or json")
+
+	return cmd
+}
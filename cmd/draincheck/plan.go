@@ -0,0 +1,101 @@
+package draincheck
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/fhke/kubectl-draincheck/pkg/auth"
+	"github.com/fhke/kubectl-draincheck/pkg/checker"
+	"github.com/spf13/cobra"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+)
+
+func newPlanCmd(configFlags *genericclioptions.ConfigFlags, skipAuthCheck *bool) *cobra.Command {
+	var (
+		// flags
+		output       *string
+		timeout      *time.Duration
+		nodes        *[]string
+		drainOptions = &checker.DrainOptions{}
+	)
+
+	cmd := &cobra.Command{
+		Use:   "plan --nodes NODE[,NODE...]",
+		Short: "Plan the order in which to drain nodes without pod disruption budgets blocking evictions",
+		Long: `Plan the order in which to drain nodes.
+
+Nodes are grouped into batches. Nodes in the same batch can be drained in parallel
+without any pod disruption budget blocking evictions, and batches should be drained
+in order, waiting for evicted pods to become healthy before draining the next batch.
+Nodes that cannot be drained without a pod disruption budget blocking evictions, or
+that kubectl drain would refuse to drain, are reported as blocked.`,
+		Args: cobra.NoArgs,
+
+		// Validate args
+		PreRun: func(cmd *cobra.Command, args []string) {
+			if len(*nodes) == 0 {
+				log.Panic("at least one node must be specified with --nodes")
+			}
+			if *output != OutputYAML && *output != OutputJSON && *output != OutputText {
+				log.Panicf("Unexpected output format %s. Valid values are %s, %s or %s", *output, OutputJSON, OutputYAML, OutputText)
+			}
+		},
+
+		Run: func(cmd *cobra.Command, args []string) {
+			// exit with non-zero code after other deferred calls have run
+			var exitCode int
+			defer func() {
+				if exitCode != 0 {
+					os.Exit(exitCode)
+				}
+			}()
+			defer log.Sync()
+
+			// create parent context
+			ctx := context.Background()
+
+			cs := mustNewClientset(configFlags)
+
+			// check permissions to list pods & pod disruption budgets in all namespaces
+			if !*skipAuthCheck && !preflight(ctx, cs, *timeout, "", auth.Scope{DrainFilters: true}) {
+				exitCode = ExitCodeMissingPermissions
+				return
+			}
+
+			// create eviction checker
+			ch := mustNewChecker(ctx, cs, *timeout, checker.WithDrainOptions(*drainOptions))
+			defer ch.Stop()
+
+			plan, err := ch.PlanNodes(ctx, *timeout, *nodes...)
+			if err != nil {
+				log.Panicw("Error planning node drains", "error", err)
+			}
+
+			// Write data in preferred format
+			switch *output {
+			case OutputText:
+				fmt.Print(string(plan.Table()))
+			case OutputYAML:
+				mustMarshalWrite(log, plan.YAML)
+			case OutputJSON:
+				mustMarshalWrite(log, plan.JSON)
+			default:
+				// We should never get here, as invalid options should be
+				// picked up in PreRun
+				log.Panicw("Internal error: no formatter found", "output", *output)
+			}
+		},
+	}
+
+	nodes = cmd.Flags().StringSlice("nodes", nil, "Nodes to drain")
+	timeout = cmd.Flags().DurationP("api-timeout", "T", time.Second*30, "Timeout for calls to Kubernetes API server")
+	output = cmd.Flags().StringP("output", "o", OutputText, "Output format - yaml, json or text")
+	cmd.Flags().BoolVar(&drainOptions.IgnoreDaemonSets, "ignore-daemonsets", false, "Skip pods managed by daemonsets, as kubectl drain --ignore-daemonsets would")
+	cmd.Flags().BoolVar(&drainOptions.DeleteEmptyDirData, "delete-emptydir-data", false, "Allow pods using emptyDir volumes, as kubectl drain --delete-emptydir-data would")
+	cmd.Flags().BoolVar(&drainOptions.Force, "force", false, "Allow pods that are not managed by a controller, as kubectl drain --force would")
+	cmd.Flags().BoolVar(&drainOptions.DisableEviction, "disable-eviction", false, "Delete pods without using the eviction API, as kubectl drain --disable-eviction would")
+
+	return cmd
+}
@@ -0,0 +1,47 @@
+package auth
+
+import (
+	"bytes"
+	"encoding/json"
+	"strconv"
+
+	"github.com/olekukonko/tablewriter"
+	"sigs.k8s.io/yaml"
+)
+
+// Convert results to JSON
+func (r Results) JSON() ([]byte, error) {
+	return json.MarshalIndent(r, "", "    ")
+}
+
+// Convert results to YAML
+func (r Results) YAML() ([]byte, error) {
+	return yaml.Marshal(r)
+}
+
+// Convert results to a human-readable table
+func (r Results) Table() []byte {
+	// Buffer to store table data
+	var buf = &bytes.Buffer{}
+
+	// Prepare table
+	tbl := tablewriter.NewWriter(buf)
+	tbl.SetAutoWrapText(false)
+	tbl.SetHeader([]string{"verb", "resource", "scope", "allowed", "needed to"})
+
+	// Load table with data
+	for _, res := range r {
+		tbl.Append([]string{
+			res.Rule.Verb,
+			res.Rule.resource(),
+			res.Rule.scope(),
+			strconv.FormatBool(res.Allowed),
+			res.Rule.Reason,
+		})
+	}
+
+	// render table
+	tbl.Render()
+
+	return buf.Bytes()
+}
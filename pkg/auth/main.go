@@ -0,0 +1,146 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// Get the permissions that the checker needs for a scope
+func Requirements(s Scope) Rules {
+	rules := Rules{
+		{Verb: "list", Resource: "pods", Namespace: s.Namespace, Reason: "list pods to check"},
+		{Verb: "get", Resource: "pods", Namespace: s.Namespace, Reason: "get pods to check by name"},
+		{Verb: "list", Group: "policy", Resource: "poddisruptionbudgets", Namespace: s.Namespace, Reason: "cache pod disruption budgets"},
+		{Verb: "watch", Group: "policy", Resource: "poddisruptionbudgets", Namespace: s.Namespace, Reason: "cache pod disruption budgets"},
+	}
+	if s.WatchPods {
+		rules = append(rules, Rule{Verb: "watch", Resource: "pods", Namespace: s.Namespace, Reason: "cache pods"})
+	}
+	if s.Evict {
+		rules = append(rules, Rule{Verb: "create", Resource: "pods", Subresource: "eviction", Namespace: s.Namespace, Reason: "run eviction dry-runs"})
+	}
+	if s.DrainFilters {
+		rules = append(rules, Rule{Verb: "get", Group: "apps", Resource: "daemonsets", Namespace: s.Namespace, Reason: "check whether daemonsets managing pods exist"})
+	}
+	if s.ListNodes {
+		rules = append(rules, Rule{Verb: "list", Resource: "nodes", Reason: "list nodes matching selector"})
+	}
+	if s.ListNamespaces {
+		rules = append(rules, Rule{Verb: "list", Resource: "namespaces", Reason: "list namespaces matching selector"})
+	}
+
+	return rules
+}
+
+// Check whether the current user has permissions, with SelfSubjectAccessReviews
+func Check(ctx context.Context, k kubernetes.Interface, rules Rules) (Results, error) {
+	results := make(Results, 0, len(rules))
+
+	for _, rule := range rules {
+		review, err := k.AuthorizationV1().SelfSubjectAccessReviews().Create(ctx, &authorizationv1.SelfSubjectAccessReview{
+			Spec: authorizationv1.SelfSubjectAccessReviewSpec{
+				ResourceAttributes: &authorizationv1.ResourceAttributes{
+					Namespace:   rule.Namespace,
+					Verb:        rule.Verb,
+					Group:       rule.Group,
+					Resource:    rule.Resource,
+					Subresource: rule.Subresource,
+				},
+			},
+		}, metav1.CreateOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("error reviewing access to %s: %w", rule, err)
+		}
+
+		results = append(results, Result{
+			Rule:    rule,
+			Allowed: review.Status.Allowed,
+			Reason:  review.Status.Reason,
+		})
+	}
+
+	return results, nil
+}
+
+// Get results for permissions that are missing
+func (r Results) Missing() Results {
+	var out Results
+
+	for _, res := range r {
+		if !res.Allowed {
+			out = append(out, res)
+		}
+	}
+
+	return out
+}
+
+// Describe a rule, e.g. create pods/eviction in namespace default
+func (r Rule) String() string {
+	return fmt.Sprintf("%s %s in %s", r.Verb, r.resource(), r.scope())
+}
+
+// get the resource with group & subresource, e.g. poddisruptionbudgets.policy
+func (r Rule) resource() string {
+	resource := r.Resource
+	if r.Group != "" {
+		resource += "." + r.Group
+	}
+	if r.Subresource != "" {
+		resource += "/" + r.Subresource
+	}
+	return resource
+}
+
+// get the scope of a rule
+func (r Rule) scope() string {
+	if r.Namespace == "" {
+		return "all namespaces"
+	}
+	return "namespace " + r.Namespace
+}
+
+// Convert rules to RBAC policy rules, merging the verbs of rules for the same resource
+func (r Rules) PolicyRules() []rbacv1.PolicyRule {
+	var (
+		out   []rbacv1.PolicyRule
+		index = make(map[string]int) // index of policy rule in out for each resource
+	)
+
+	for _, rule := range r {
+		key := rule.resource()
+		if i, ok := index[key]; ok {
+			out[i].Verbs = append(out[i].Verbs, rule.Verb)
+			continue
+		}
+
+		resource := rule.Resource
+		if rule.Subresource != "" {
+			resource += "/" + rule.Subresource
+		}
+		index[key] = len(out)
+		out = append(out, rbacv1.PolicyRule{
+			APIGroups: []string{rule.Group},
+			Resources: []string{resource},
+			Verbs:     []string{rule.Verb},
+		})
+	}
+
+	return out
+}
+
+// Check whether any rules are for cluster-scoped resources
+func (r Rules) ClusterScoped() bool {
+	for _, rule := range r {
+		if rule.Resource == "nodes" || rule.Resource == "namespaces" {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,63 @@
+package auth
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestRequirements(t *testing.T) {
+	t.Parallel()
+
+	minimal := Requirements(Scope{Namespace: "foo"})
+	assert.Len(t, minimal, 4, "Minimal scope should need pod & PDB permissions only")
+	for _, rule := range minimal {
+		assert.Equal(t, "foo", rule.Namespace, "Rules should be scoped to namespace")
+	}
+
+	full := Requirements(Scope{Evict: true, DrainFilters: true, ListNodes: true, ListNamespaces: true, WatchPods: true})
+	assert.Contains(t, full, Rule{Verb: "create", Resource: "pods", Subresource: "eviction", Reason: "run eviction dry-runs"})
+	assert.Contains(t, full, Rule{Verb: "list", Resource: "nodes", Reason: "list nodes matching selector"})
+	assert.Contains(t, full, Rule{Verb: "list", Resource: "namespaces", Reason: "list namespaces matching selector"})
+	assert.Len(t, full, 9, "Full scope should need all permissions")
+}
+
+func TestCheck(t *testing.T) {
+	t.Parallel()
+
+	// only allow access to pods
+	cs := fake.NewSimpleClientset()
+	cs.PrependReactor("create", "selfsubjectaccessreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		review := action.(k8stesting.CreateAction).GetObject().(*authorizationv1.SelfSubjectAccessReview)
+		review.Status.Allowed = review.Spec.ResourceAttributes.Resource == "pods" && review.Spec.ResourceAttributes.Subresource == ""
+		return true, review, nil
+	})
+
+	res, err := Check(context.Background(), cs, Requirements(Scope{Evict: true}))
+	require.NoError(t, err, "Check should not return error")
+	assert.Len(t, res, 5, "All rules should be checked")
+
+	missing := res.Missing()
+	if assert.Len(t, missing, 3, "PDB & eviction permissions should be missing") {
+		assert.Equal(t, "list poddisruptionbudgets.policy in all namespaces", missing[0].Rule.String())
+		assert.Equal(t, "create pods/eviction in all namespaces", missing[2].Rule.String())
+	}
+}
+
+func TestPolicyRules(t *testing.T) {
+	t.Parallel()
+
+	rules := Requirements(Scope{Evict: true}).PolicyRules()
+	assert.Equal(t, []rbacv1.PolicyRule{
+		{APIGroups: []string{""}, Resources: []string{"pods"}, Verbs: []string{"list", "get"}},
+		{APIGroups: []string{"policy"}, Resources: []string{"poddisruptionbudgets"}, Verbs: []string{"list", "watch"}},
+		{APIGroups: []string{""}, Resources: []string{"pods/eviction"}, Verbs: []string{"create"}},
+	}, rules, "Verbs should be merged for each resource")
+}
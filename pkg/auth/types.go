@@ -0,0 +1,32 @@
+package auth
+
+type (
+	// What the checker needs permission to do, & how it is configured
+	Scope struct {
+		Namespace      string // namespace that pods are checked in, or all namespaces if empty
+		Evict          bool   // eviction dry-runs are run, rather than evaluating PDBs offline
+		DrainFilters   bool   // kubectl drain filters are applied, which get daemonsets
+		ListNodes      bool   // nodes are listed by label selector
+		ListNamespaces bool   // namespaces are listed by label selector
+		WatchPods      bool   // pods are cached with an informer
+	}
+
+	// A permission needed by the checker
+	Rule struct {
+		Verb        string `json:"verb"`
+		Group       string `json:"group"`
+		Resource    string `json:"resource"`
+		Subresource string `json:"subresource,omitempty"`
+		Namespace   string `json:"namespace,omitempty"` // empty for all namespaces or cluster-scoped resources
+		Reason      string `json:"reason"`              // what the permission is needed for
+	}
+	Rules []Rule
+
+	// Whether the current user has a permission
+	Result struct {
+		Rule    Rule   `json:"rule"`
+		Allowed bool   `json:"allowed"`
+		Reason  string `json:"reason,omitempty"` // reason given by the authorizer
+	}
+	Results []Result
+)
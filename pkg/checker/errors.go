@@ -0,0 +1,24 @@
+package checker
+
+import (
+	"fmt"
+
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+)
+
+var _ error = &IncompleteError{}
+
+func (e *IncompleteError) Error() string {
+	return fmt.Sprintf("some pods could not be checked: %s", utilerrors.NewAggregate(e.Errors))
+}
+
+// Get an IncompleteError for errors, or nil if there are no errors
+func incompleteError(errs []error) error {
+	if len(errs) == 0 {
+		return nil
+	}
+
+	return &IncompleteError{
+		Errors: errs,
+	}
+}
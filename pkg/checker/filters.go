@@ -0,0 +1,131 @@
+package checker
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	DispositionEvict  Disposition = "Evict"  // pod is evicted with the eviction API
+	DispositionSkip   Disposition = "Skip"   // pod is ignored
+	DispositionRefuse Disposition = "Refuse" // drain fails because of pod
+	DispositionDelete Disposition = "Delete" // pod is deleted without using the eviction API
+)
+
+var (
+	ErrNoController      = errors.New("pod is not managed by a controller")
+	ErrDaemonSetPod      = errors.New("pod is managed by a daemonset")
+	ErrDaemonSetNotFound = errors.New("pod is managed by a daemonset that does not exist")
+	ErrLocalStorage      = errors.New("pod has local storage")
+)
+
+// A filter returns the disposition for a pod & the reason that kubectl drain
+// would refuse to remove it. Filters mirror the filters used by kubectl drain.
+type filter func(ctx context.Context, pod *corev1.Pod, timeout time.Duration) (Disposition, error, error)
+
+// Get the disposition that kubectl drain would give a pod, along with the reason
+// that drain would refuse to remove it. Filters are applied in the same order as
+// kubectl drain, stopping at the first filter that does not remove the pod.
+func (c *Checker) drainDisposition(ctx context.Context, pod *corev1.Pod, timeout time.Duration) (Disposition, error, error) {
+	filters := []filter{
+		c.daemonSetFilter,
+		c.mirrorPodFilter,
+		c.localStorageFilter,
+		c.unreplicatedFilter,
+	}
+
+	for _, f := range filters {
+		disposition, reason, err := f(ctx, pod, timeout)
+		if err != nil || disposition != DispositionEvict {
+			return disposition, reason, err
+		}
+	}
+
+	if c.drainOptions.DisableEviction {
+		// pod is deleted directly, bypassing pod disruption budgets
+		return DispositionDelete, nil, nil
+	}
+
+	return DispositionEvict, nil, nil
+}
+
+// Refuse to remove pods managed by daemonsets, unless daemonsets are ignored
+func (c *Checker) daemonSetFilter(ctx context.Context, pod *corev1.Pod, timeout time.Duration) (Disposition, error, error) {
+	ref := metav1.GetControllerOf(pod)
+	if ref == nil || ref.Kind != "DaemonSet" || isPodTerminated(pod) {
+		return DispositionEvict, nil, nil
+	}
+
+	err := c.retry(ctx, timeout, func(ctx context.Context) error {
+		_, err := c.k.AppsV1().DaemonSets(pod.Namespace).Get(ctx, ref.Name, metav1.GetOptions{})
+		return err
+	})
+	if err != nil {
+		if !kerrors.IsNotFound(err) {
+			return "", nil, err
+		}
+		// orphaned pods are removed if --force is used
+		if c.drainOptions.Force {
+			return DispositionEvict, nil, nil
+		}
+		return DispositionRefuse, ErrDaemonSetNotFound, nil
+	}
+
+	if !c.drainOptions.IgnoreDaemonSets {
+		return DispositionRefuse, ErrDaemonSetPod, nil
+	}
+
+	return DispositionSkip, nil, nil
+}
+
+// Skip mirror pods, as they are managed by the kubelet
+func (c *Checker) mirrorPodFilter(_ context.Context, pod *corev1.Pod, _ time.Duration) (Disposition, error, error) {
+	if _, ok := pod.Annotations[corev1.MirrorPodAnnotationKey]; ok {
+		return DispositionSkip, nil, nil
+	}
+
+	return DispositionEvict, nil, nil
+}
+
+// Refuse to remove running pods with emptyDir volumes, unless emptyDir data can be deleted
+func (c *Checker) localStorageFilter(_ context.Context, pod *corev1.Pod, _ time.Duration) (Disposition, error, error) {
+	if !hasLocalStorage(pod) || isPodTerminated(pod) || c.drainOptions.DeleteEmptyDirData {
+		return DispositionEvict, nil, nil
+	}
+
+	return DispositionRefuse, ErrLocalStorage, nil
+}
+
+// Refuse to remove running pods that are not managed by a controller, unless --force is used
+func (c *Checker) unreplicatedFilter(_ context.Context, pod *corev1.Pod, _ time.Duration) (Disposition, error, error) {
+	if isPodTerminated(pod) || metav1.GetControllerOf(pod) != nil || c.drainOptions.Force {
+		return DispositionEvict, nil, nil
+	}
+
+	if len(pod.OwnerReferences) == 0 {
+		return DispositionRefuse, ErrNoOwnerRefs, nil
+	}
+
+	return DispositionRefuse, ErrNoController, nil
+}
+
+// check whether a pod has an emptyDir volume
+func hasLocalStorage(pod *corev1.Pod) bool {
+	for _, volume := range pod.Spec.Volumes {
+		if volume.EmptyDir != nil {
+			return true
+		}
+	}
+
+	return false
+}
+
+// check whether a pod has succeeded or failed
+func isPodTerminated(pod *corev1.Pod) bool {
+	return pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed
+}
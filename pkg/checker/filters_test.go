@@ -0,0 +1,90 @@
+package checker
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestDrainDisposition(t *testing.T) {
+	t.Parallel()
+
+	var (
+		controller = true
+		managed    = []metav1.OwnerReference{{Kind: "ReplicaSet", Name: "rs", Controller: &controller}}
+		emptyDir   = []corev1.Volume{{Name: "tmp", VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}}}}
+	)
+
+	tests := []struct {
+		name        string
+		opts        DrainOptions
+		pod         corev1.Pod
+		disposition Disposition
+		reason      error
+	}{
+		{
+			name:        "managed pod",
+			pod:         corev1.Pod{ObjectMeta: metav1.ObjectMeta{OwnerReferences: managed}},
+			disposition: DispositionEvict,
+		},
+		{
+			name:        "managed pod with eviction disabled",
+			opts:        DrainOptions{DisableEviction: true},
+			pod:         corev1.Pod{ObjectMeta: metav1.ObjectMeta{OwnerReferences: managed}},
+			disposition: DispositionDelete,
+		},
+		{
+			name:        "mirror pod",
+			pod:         corev1.Pod{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{corev1.MirrorPodAnnotationKey: ""}}},
+			disposition: DispositionSkip,
+		},
+		{
+			name:        "pod with local storage",
+			pod:         corev1.Pod{ObjectMeta: metav1.ObjectMeta{OwnerReferences: managed}, Spec: corev1.PodSpec{Volumes: emptyDir}},
+			disposition: DispositionRefuse,
+			reason:      ErrLocalStorage,
+		},
+		{
+			name:        "pod with local storage & --delete-emptydir-data",
+			opts:        DrainOptions{DeleteEmptyDirData: true},
+			pod:         corev1.Pod{ObjectMeta: metav1.ObjectMeta{OwnerReferences: managed}, Spec: corev1.PodSpec{Volumes: emptyDir}},
+			disposition: DispositionEvict,
+		},
+		{
+			name:        "unmanaged pod",
+			pod:         corev1.Pod{},
+			disposition: DispositionRefuse,
+			reason:      ErrNoOwnerRefs,
+		},
+		{
+			name:        "pod with owner but no controller",
+			pod:         corev1.Pod{ObjectMeta: metav1.ObjectMeta{OwnerReferences: []metav1.OwnerReference{{Kind: "Foo"}}}},
+			disposition: DispositionRefuse,
+			reason:      ErrNoController,
+		},
+		{
+			name:        "unmanaged pod with --force",
+			opts:        DrainOptions{Force: true},
+			pod:         corev1.Pod{},
+			disposition: DispositionEvict,
+		},
+		{
+			name:        "terminated unmanaged pod",
+			pod:         corev1.Pod{Status: corev1.PodStatus{Phase: corev1.PodSucceeded}},
+			disposition: DispositionEvict,
+		},
+	}
+
+	for _, tt := range tests {
+		c := &Checker{drainOptions: tt.opts}
+		disposition, reason, err := c.drainDisposition(context.TODO(), &tt.pod, time.Second)
+		require.NoErrorf(t, err, "Getting disposition should not return error for %s", tt.name)
+		assert.Equalf(t, tt.disposition, disposition, "Disposition should be correct for %s", tt.name)
+		assert.Equalf(t, tt.reason, reason, "Reason should be correct for %s", tt.name)
+	}
+}
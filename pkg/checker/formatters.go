@@ -3,6 +3,9 @@ package checker
 import (
 	"bytes"
 	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/fhke/kubectl-draincheck/pkg/checker/errors"
@@ -17,28 +20,141 @@ func (r Results) JSON() ([]byte, error) {
 	return json.MarshalIndent(r.marshalPrepare(), "", "    ")
 }
 
+// Convert a single result to a line of newline-delimited JSON
+func (r Result) NDJSON() ([]byte, error) {
+	data, err := json.Marshal(Results{r}.marshalPrepare()[0])
+	if err != nil {
+		return nil, err
+	}
+
+	return append(data, '\n'), nil
+}
+
 // Convert results to YAML
 func (r Results) YAML() ([]byte, error) {
 	return yaml.Marshal(r.marshalPrepare())
 }
 
+// Include the node that each pod is scheduled to in a table, grouping
+// pods by node
+func WithNodeColumn() TableOption {
+	return func(tc *tableConfig) {
+		tc.nodeColumn = true
+	}
+}
+
+// Include the cluster that each pod is in in a table, grouping pods by cluster
+func WithClusterColumn() TableOption {
+	return func(tc *tableConfig) {
+		tc.clusterColumn = true
+	}
+}
+
+// Include the status of each pod in a table, for results of all pods
+func WithStatusColumn() TableOption {
+	return func(tc *tableConfig) {
+		tc.statusColumn = true
+	}
+}
+
 // Convert results to a human-readable table
-func (r Results) Table() []byte {
+func (r Results) Table(opts ...TableOption) []byte {
+	// Apply options
+	tc := &tableConfig{}
+	for _, opt := range opts {
+		opt(tc)
+	}
+
 	// Buffer to store table data
 	var buf = &bytes.Buffer{}
 
 	// Prepare table
 	tbl := tablewriter.NewWriter(buf)
-	tbl.SetHeader([]string{"namespace", "pod", "reason", "pod disruption budgets"})
 	tbl.SetAutoWrapText(false)
 
+	var (
+		header       = []string{"namespace", "pod"}
+		mergeColumns []int
+	)
+	if tc.statusColumn {
+		header = append(header, "status")
+	}
+	header = append(header, "disposition", "reason", "pod disruption budgets")
+	if tc.nodeColumn {
+		// sort results so that pods on the same node are grouped together
+		r = r.sortedByNode()
+		header = append([]string{"node"}, header...)
+		mergeColumns = append(mergeColumns, 0)
+	}
+	if tc.clusterColumn {
+		// sort results so that pods in the same cluster are grouped together
+		r = r.sortedByCluster()
+		header = append([]string{"cluster"}, header...)
+		for i := range mergeColumns {
+			mergeColumns[i]++
+		}
+		mergeColumns = append([]int{0}, mergeColumns...)
+	}
+	tbl.SetHeader(header)
+	if len(mergeColumns) > 0 {
+		tbl.SetAutoMergeCellsByColumnIndex(mergeColumns)
+	}
+
 	// Load table with data
 	for _, res := range r {
+		row := []string{res.Pod.Namespace, res.Pod.Name}
+		if tc.statusColumn {
+			row = append(row, string(res.Status))
+		}
+		row = append(row, string(res.Disposition), res.reason(), res.pdbNames())
+		if tc.nodeColumn {
+			row = append([]string{res.Pod.Spec.NodeName}, row...)
+		}
+		if tc.clusterColumn {
+			row = append([]string{res.Cluster}, row...)
+		}
+		tbl.Append(row)
+	}
+
+	// render table
+	tbl.Render()
+
+	return buf.Bytes()
+}
+
+// Convert plan to JSON
+func (p *Plan) JSON() ([]byte, error) {
+	return json.MarshalIndent(p.marshalPrepare(), "", "    ")
+}
+
+// Convert plan to YAML
+func (p *Plan) YAML() ([]byte, error) {
+	return yaml.Marshal(p.marshalPrepare())
+}
+
+// Convert plan to a human-readable table
+func (p *Plan) Table() []byte {
+	// Buffer to store table data
+	var buf = &bytes.Buffer{}
+
+	// Prepare table
+	tbl := tablewriter.NewWriter(buf)
+	tbl.SetHeader([]string{"batch", "node", "reason", "pod disruption budgets"})
+	tbl.SetAutoWrapText(false)
+	tbl.SetAutoMergeCellsByColumnIndex([]int{0})
+
+	// Load table with data
+	for i, batch := range p.Batches {
+		for _, node := range batch.Nodes {
+			tbl.Append([]string{strconv.Itoa(i + 1), node, "", ""})
+		}
+	}
+	for _, blocked := range p.Blocked {
 		tbl.Append([]string{
-			res.Pod.Namespace,
-			res.Pod.Name,
-			res.Reason.Error(),
-			res.pdbNames(),
+			"blocked",
+			blocked.Node,
+			blocked.Reason.Error(),
+			pdbNames(blocked.PodDisruptionBudgets),
 		})
 	}
 
@@ -48,6 +164,25 @@ func (r Results) Table() []byte {
 	return buf.Bytes()
 }
 
+// Set the Reason field of blocked nodes to an error type that can be
+// marshalled to text, and remove managed fields from resources
+func (p *Plan) marshalPrepare() *Plan {
+	out := &Plan{
+		Batches: p.Batches,
+		Blocked: make([]BlockedNode, len(p.Blocked)),
+	}
+
+	for i := range p.Blocked {
+		out.Blocked[i] = BlockedNode{
+			Node:                 p.Blocked[i].Node,
+			Reason:               errors.For(p.Blocked[i].Reason),
+			PodDisruptionBudgets: removeManagedFieldsPDBSlice(p.Blocked[i].PodDisruptionBudgets),
+		}
+	}
+
+	return out
+}
+
 // Set the Reason field to an error type that can be marshalled
 // to text, and remove managed fields from resources
 func (r Results) marshalPrepare() Results {
@@ -67,11 +202,53 @@ func (r Results) marshalPrepare() Results {
 	return out
 }
 
+// Copy results, sorting by node. The order of results on the same node is preserved.
+func (r Results) sortedByNode() Results {
+	out := make(Results, len(r))
+	copy(out, r)
+
+	sort.SliceStable(out, func(i, j int) bool {
+		return out[i].Pod.Spec.NodeName < out[j].Pod.Spec.NodeName
+	})
+
+	return out
+}
+
+// Copy results, sorting by cluster. The order of results in the same cluster is preserved.
+func (r Results) sortedByCluster() Results {
+	out := make(Results, len(r))
+	copy(out, r)
+
+	sort.SliceStable(out, func(i, j int) bool {
+		return out[i].Cluster < out[j].Cluster
+	})
+
+	return out
+}
+
+// Get the reason a pod cannot be evicted, including the pod disruption budget that
+// blocked eviction if known, or "" if it can be evicted
+func (r Result) reason() string {
+	if r.Reason == nil {
+		return ""
+	}
+	if r.BlockingPDB != "" {
+		return fmt.Sprintf("%s (%s)", r.Reason, r.BlockingPDB)
+	}
+
+	return r.Reason.Error()
+}
+
 // Get comma-separated names of pod disruption budgets affecting pod
 func (r Result) pdbNames() string {
-	names := make([]string, len(r.PodDisruptionBudgets))
+	return pdbNames(r.PodDisruptionBudgets)
+}
+
+// Get comma-separated names of pod disruption budgets
+func pdbNames(pdbs []*v1.PodDisruptionBudget) string {
+	names := make([]string, len(pdbs))
 
-	for i, pdb := range r.PodDisruptionBudgets {
+	for i, pdb := range pdbs {
 		names[i] = pdb.Name
 	}
 
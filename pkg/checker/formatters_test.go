@@ -0,0 +1,136 @@
+package checker
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/fhke/kubectl-draincheck/pkg/evictor"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestTableClusterColumn(t *testing.T) {
+	t.Parallel()
+
+	newResult := func(cluster, node, name string) Result {
+		return Result{
+			Cluster: cluster,
+			Reason:  errors.New("blocked"),
+			Pod: corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name},
+				Spec:       corev1.PodSpec{NodeName: node},
+			},
+		}
+	}
+	res := Results{
+		newResult("prod", "node-2", "a"),
+		newResult("dev", "node-1", "b"),
+		newResult("prod", "node-1", "c"),
+	}
+
+	lines := strings.Split(string(res.Table(WithClusterColumn(), WithNodeColumn())), "\n")
+	assert.Contains(t, lines[1], "CLUSTER", "Cluster column should be first")
+	assert.Contains(t, lines[1], "NODE", "Node column should be included")
+
+	// rows should be grouped by cluster, then node
+	var order []string
+	for _, line := range lines {
+		for _, name := range []string{" a ", " b ", " c "} {
+			if strings.Contains(line, name) {
+				order = append(order, strings.TrimSpace(name))
+			}
+		}
+	}
+	assert.Equal(t, []string{"b", "c", "a"}, order, "Results should be sorted by cluster, then node")
+}
+
+func TestTableStatusColumn(t *testing.T) {
+	t.Parallel()
+
+	res := Results{
+		{Status: StatusEvictable, Disposition: DispositionEvict, Pod: corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "a"}}},
+		{Status: StatusUnevictable, Disposition: DispositionEvict, Reason: errors.New("blocked"), BlockingPDB: "foo", Pod: corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "b"}}},
+	}
+
+	lines := strings.Split(string(res.Table(WithStatusColumn())), "\n")
+	assert.Regexp(t, `POD +\| +STATUS +\| +DISPOSITION`, lines[1], "Status column should follow pod column")
+	assert.Regexp(t, `a +\| +Evictable +\| +Evict +\| +\|`, lines[3], "Evictable pods should have an empty reason")
+	assert.Regexp(t, `b +\| +Unevictable +\| +Evict +\| +blocked \(foo\)`, lines[4], "Unevictable pods should have a reason naming the blocking PDB")
+}
+
+func TestTableNodeColumn(t *testing.T) {
+	t.Parallel()
+
+	newResult := func(node, name string) Result {
+		return Result{
+			Reason: errors.New("blocked"),
+			Pod: corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name},
+				Spec:       corev1.PodSpec{NodeName: node},
+			},
+		}
+	}
+	res := Results{
+		newResult("node-2", "a"),
+		newResult("node-1", "b"),
+		newResult("node-2", "c"),
+		newResult("node-1", "d"),
+	}
+
+	lines := strings.Split(string(res.Table(WithNodeColumn())), "\n")
+	assert.Regexp(t, `^\| +NODE +\| +NAMESPACE`, lines[1], "Node column should be first")
+	assert.Contains(t, lines[3], "node-1", "First row should show node")
+	assert.NotContains(t, lines[4], "node-1", "Node should be merged for rows on the same node")
+
+	// rows should be grouped by node, preserving order within each node
+	var order []string
+	for _, line := range lines {
+		for _, name := range []string{" a ", " b ", " c ", " d "} {
+			if strings.Contains(line, name) {
+				order = append(order, strings.TrimSpace(name))
+			}
+		}
+	}
+	assert.Equal(t, []string{"b", "d", "a", "c"}, order, "Results should be sorted by node")
+	assert.Equal(t, "node-2", res[0].Pod.Spec.NodeName, "Sorting for table should not modify results")
+}
+
+func TestResultNDJSON(t *testing.T) {
+	t.Parallel()
+
+	res := Result{
+		Reason:      &evictor.EvictionError{Reason: evictor.ErrNoDisruptions, PDBName: "foo"},
+		Status:      StatusUnevictable,
+		BlockingPDB: "foo",
+		Disposition: DispositionEvict,
+		Pod: corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace:     "default",
+				Name:          "a",
+				ManagedFields: []metav1.ManagedFieldsEntry{{Manager: "kubectl"}},
+			},
+		},
+	}
+
+	data, err := res.NDJSON()
+	require.NoError(t, err, "Marshalling result should not return error")
+	assert.True(t, bytes.HasSuffix(data, []byte("\n")), "Result should end with a newline")
+	assert.Equal(t, 1, bytes.Count(data, []byte("\n")), "Result should be written on a single line")
+
+	var line map[string]interface{}
+	require.NoError(t, json.Unmarshal(data, &line), "Line should be valid JSON")
+	assert.Equal(t, res.Reason.Error(), line["reason"], "Reason should be marshalled as text")
+	assert.Equal(t, string(StatusUnevictable), line["status"])
+	assert.Equal(t, "foo", line["blockingPodDisruptionBudget"])
+	assert.NotContains(t, line, "cluster", "Cluster should be omitted when not set")
+
+	meta := line["pod"].(map[string]interface{})["metadata"].(map[string]interface{})
+	assert.Equal(t, "a", meta["name"])
+	assert.NotContains(t, meta, "managedFields", "Managed fields should be removed")
+	assert.NotEmpty(t, res.Pod.ManagedFields, "Marshalling should not modify the result")
+}
@@ -4,62 +4,234 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"strings"
+	"sort"
 	"sync"
 	"time"
 
 	"github.com/fhke/kubectl-draincheck/pkg/evictor"
+	"github.com/fhke/kubectl-draincheck/pkg/retry"
 	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
 )
 
-var ErrNoOwnerRefs = errors.New("pod has no owner references")
+const (
+	StatusEvictable   Status = "Evictable"   // pod can be evicted, or is deleted without eviction
+	StatusUnevictable Status = "Unevictable" // pod cannot be evicted
+	StatusSkipped     Status = "Skipped"     // pod is ignored by kubectl drain
+	StatusError       Status = "Error"       // pod could not be checked
+)
 
-// Check eligibility of all pods to be evicted
-func (c *Checker) AllPods(ctx context.Context, namespace string, timeout time.Duration, workers uint) (Results, error) {
-	// list pods on cluster
-	ctx2, can := context.WithTimeout(ctx, timeout)
-	defer can()
-	podList, err := c.k.CoreV1().Pods(namespace).List(ctx2, metav1.ListOptions{})
-	if err != nil {
-		return nil, fmt.Errorf("error listing pods: %w", err)
-	}
+var (
+	ErrNoOwnerRefs     = errors.New("pod has no owner references")
+	ErrBudgetExhausted = errors.New("pod disruption budget would be exhausted by earlier evictions")
+	ErrOtherNamespace  = errors.New("checker is scoped to a different namespace")
+	ErrNoWorkers       = errors.New("at least one worker is needed to check pods")
+)
 
-	return c.checkPods(ctx, timeout, workers, podList.Items...)
+// Check eligibility of all pods to be evicted. Namespace patterns & selectors are
+// applied before any pods are checked.
+func (c *Checker) AllPods(ctx context.Context, namespace string, timeout time.Duration, workers uint, opts ...CheckOption) (Results, error) {
+	cc := newCheckConfig(opts...)
+
+	// list pods on cluster & check them
+	return c.checkListedPods(ctx, namespace, timeout, workers, cc, cc.listOptions())
 }
 
-// Check eligibility of pods by name
-func (c *Checker) PodsByName(ctx context.Context, timeout time.Duration, namespace string, workers uint, podNames ...string) (Results, error) {
-	var pods []corev1.Pod
+// Check eligibility of all pods on a node to be evicted, in the same way
+// that kubectl drain selects pods for a node
+func (c *Checker) Node(ctx context.Context, nodeName string, timeout time.Duration, workers uint, opts ...CheckOption) (Results, error) {
+	cc := newCheckConfig(opts...)
 
-	// get pods
+	// list pods scheduled to node in all namespaces & check them
+	return c.checkListedPods(
+		ctx,
+		"",
+		timeout,
+		workers,
+		cc,
+		cc.listOptions(fields.OneTermEqualSelector("spec.nodeName", nodeName).String()),
+	)
+}
+
+// Check eligibility of pods by name. Pods that cannot be found are reported with
+// StatusError, & an IncompleteError is returned.
+func (c *Checker) PodsByName(ctx context.Context, timeout time.Duration, namespace string, workers uint, podNames []string, opts ...CheckOption) (Results, error) {
+	cc := newCheckConfig(opts...)
+	if err := c.checkNamespace(namespace); err != nil {
+		return nil, err
+	}
+
+	var (
+		pods    []corev1.Pod
+		results Results
+		errs    []error
+	)
+
+	// get pods, reporting pods that can't be got without failing other pods
 	for _, podName := range podNames {
-		ctx2, can := context.WithTimeout(ctx, timeout)
-		defer can()
-		pod, err := c.k.CoreV1().Pods(namespace).Get(ctx2, podName, metav1.GetOptions{})
+		pod, err := c.getPod(ctx, namespace, podName, timeout)
 		if err != nil {
-			return nil, fmt.Errorf("error getting pod %s/%s: %w", namespace, podName, err)
+			res := Result{
+				Cluster: c.cluster,
+				Reason:  fmt.Errorf("error getting pod %s/%s: %w", namespace, podName, err),
+				Status:  StatusError,
+				Pod:     corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: podName}},
+			}
+			errs = append(errs, res.Reason)
+			results = cc.handle(results, res)
+			continue
 		}
 		pods = append(pods, *pod)
 	}
 
-	return c.checkPods(ctx, timeout, workers, pods...)
+	checked, err := c.checkPods(ctx, timeout, workers, cc, pods...)
+	if ie := (*IncompleteError)(nil); errors.As(err, &ie) {
+		errs = append(errs, ie.Errors...)
+	} else if err != nil {
+		return nil, err
+	}
+
+	return append(results, checked...), incompleteError(errs)
 }
 
-// Check eligibility of specified pods
-func (c *Checker) checkPods(ctx context.Context, timeout time.Duration, workers uint, pods ...corev1.Pod) (Results, error) {
-	// create channel for worker goroutines to read pods
-	podCh := make(chan corev1.Pod, len(pods))
+// Check eligibility of all pods on nodes matching a label selector to be evicted.
+// Results are grouped by node.
+func (c *Checker) NodesBySelector(ctx context.Context, selector string, timeout time.Duration, workers uint, opts ...CheckOption) (Results, error) {
+	// list nodes matching selector
+	var nodeList *corev1.NodeList
+	err := c.retry(ctx, timeout, func(ctx context.Context) (err error) {
+		nodeList, err = c.k.CoreV1().Nodes().List(ctx, metav1.ListOptions{
+			LabelSelector: selector,
+		})
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error listing nodes: %w", err)
+	}
 
-	// create channels for returned errors & results
-	errCh := make(chan error, len(pods))
-	resCh := make(chan Result, len(pods))
+	// sort nodes by name so results are grouped in a stable order
+	sort.Slice(nodeList.Items, func(i, j int) bool {
+		return nodeList.Items[i].Name < nodeList.Items[j].Name
+	})
 
-	// write each pod to the pod channel
-	for _, pod := range pods {
-		podCh <- pod
+	nodeNames := make([]string, len(nodeList.Items))
+	for i, node := range nodeList.Items {
+		nodeNames[i] = node.Name
 	}
-	close(podCh)
+
+	return c.Nodes(ctx, nodeNames, timeout, workers, opts...)
+}
+
+// Check eligibility of all pods on nodes to be evicted. Results are grouped by node.
+func (c *Checker) Nodes(ctx context.Context, nodeNames []string, timeout time.Duration, workers uint, opts ...CheckOption) (Results, error) {
+	var (
+		results Results
+		errs    []error
+	)
+
+	for _, nodeName := range nodeNames {
+		res, err := c.Node(ctx, nodeName, timeout, workers, opts...)
+		if ie := (*IncompleteError)(nil); errors.As(err, &ie) {
+			// keep checking other nodes if some pods could not be checked
+			errs = append(errs, ie.Errors...)
+		} else if err != nil {
+			return nil, err
+		}
+		results = append(results, res...)
+	}
+
+	return results, incompleteError(errs)
+}
+
+// List pods a page at a time, checking pods while the next page is listed. A few pages
+// of pods are queued, so that listing isn't slowed down by checks. If listing fails part
+// way through, results for pods that were listed are returned with an IncompleteError.
+// If the first page can't be listed, the list error is returned.
+func (c *Checker) checkListedPods(ctx context.Context, namespace string, timeout time.Duration, workers uint, cc *checkConfig, opts metav1.ListOptions) (Results, error) {
+	if workers == 0 {
+		return nil, ErrNoWorkers
+	}
+	if err := c.checkNamespace(namespace); err != nil {
+		return nil, err
+	}
+
+	// filter namespaces before any pods are checked
+	inNamespace, err := c.namespaceFilter(ctx, timeout, cc)
+	if err != nil {
+		return nil, err
+	}
+
+	// create channel for worker goroutines to read pods, holding a few pages
+	queueSize := c.pageSize
+	if queueSize <= 0 {
+		queueSize = DefaultPageSize
+	}
+	podCh := make(chan *corev1.Pod, queueSize*queuedPages)
+	listErrCh := make(chan error, 1)
+
+	// write pods in selected namespaces from each listed page to the pod channel. The
+	// count of listed pages is safe to read once the list error has been received.
+	var pages int
+	go func() {
+		defer close(podCh)
+		listErrCh <- c.pagePods(ctx, namespace, timeout, opts, func(page []corev1.Pod) {
+			pages++
+			for i := range page {
+				if inNamespace(page[i].Namespace) {
+					podCh <- &page[i]
+				}
+			}
+		})
+	}()
+
+	results, err := c.checkPodCh(ctx, timeout, workers, cc, podCh)
+	listErr := <-listErrCh
+	if listErr == nil {
+		return results, err
+	} else if pages == 0 {
+		// nothing was listed, so there are no results to return
+		return nil, listErr
+	}
+
+	// return results for pods that were listed
+	var errs []error
+	if ie := (*IncompleteError)(nil); errors.As(err, &ie) {
+		errs = ie.Errors
+	} else if err != nil {
+		return nil, err
+	}
+
+	return results, incompleteError(append(errs, listErr))
+}
+
+// Check eligibility of specified pods, returning results for pods that cannot be evicted
+func (c *Checker) checkPods(ctx context.Context, timeout time.Duration, workers uint, cc *checkConfig, pods ...corev1.Pod) (Results, error) {
+	if workers == 0 {
+		return nil, ErrNoWorkers
+	}
+
+	// create channel for worker goroutines to read pods
+	podCh := make(chan *corev1.Pod)
+
+	// write each pod to the pod channel
+	go func() {
+		defer close(podCh)
+		for i := range pods {
+			podCh <- &pods[i]
+		}
+	}()
+
+	return c.checkPodCh(ctx, timeout, workers, cc, podCh)
+}
+
+// Check eligibility of pods read from a channel, returning results for pods that cannot be
+// evicted, or all pods if all results are enabled. If a result handler is configured, results
+// are passed to the handler as soon as they are ready instead of being returned.
+func (c *Checker) checkPodCh(ctx context.Context, timeout time.Duration, workers uint, cc *checkConfig, podCh <-chan *corev1.Pod) (Results, error) {
+	// create channel for returned results
+	resCh := make(chan Result)
 
 	// start worker goroutines
 	wg := sync.WaitGroup{}
@@ -69,71 +241,123 @@ func (c *Checker) checkPods(ctx context.Context, timeout time.Duration, workers
 			defer wg.Done()
 			// read pod from podCh
 			for pod := range podCh {
-				res, err := c.checkPod(ctx, pod, timeout)
-				if err != nil && err != evictor.ErrNotFound {
+				res, err := c.checkPod(ctx, *pod, timeout)
+				if err == nil && res != nil && cc.allResults && res.PodDisruptionBudgets == nil {
+					// locate PDBs for pods that can be evicted or are skipped, so that
+					// every result shows the PDBs covering the pod
+					res.PodDisruptionBudgets, err = c.pdbsForPod(ctx, timeout, pod)
+				}
+				if err != nil && !errors.Is(err, evictor.ErrNotFound) {
 					// Unexpected error that is not a 404.
 					// We swallow 404 errors as we do a get/list before calling this function,
 					// so the pod was most likely deleted between initial get/list & checking.
-					errCh <- fmt.Errorf("error checking eligibility of pod %s/%s for eviction: %w", pod.Namespace, pod.Name, err)
+					resCh <- Result{
+						Reason: fmt.Errorf("error checking eligibility of pod %s/%s for eviction: %w", pod.Namespace, pod.Name, err),
+						Status: StatusError,
+						Pod:    *pod,
+					}
 				} else if res != nil {
-					// No unexpected errors but pod cannot be evicted, return error
+					// No unexpected errors, return result
 					resCh <- *res
 				}
 			}
 		}()
 	}
 
-	// wait for workers to complete
-	wg.Wait()
-	close(errCh)
-	close(resCh)
+	// close results channel once workers complete
+	go func() {
+		wg.Wait()
+		close(resCh)
+	}()
 
-	// if there were any errors, return the first one
-	for err := range errCh {
-		return nil, err
+	var (
+		results Results
+		errs    []error
+	)
+
+	for res := range resCh {
+		res.Cluster = c.cluster
+		if res.Status == StatusError {
+			errs = append(errs, res.Reason)
+		}
+
+		if cc.simulateBudgets {
+			// keep all results, as the simulation needs every pod
+			results = append(results, res)
+		} else if cc.allResults || res.Reason != nil {
+			results = cc.handle(results, res)
+		}
 	}
 
-	// read results into slice
-	var results Results
-	for result := range resCh {
-		results = append(results, result)
+	if cc.simulateBudgets {
+		// simulate evicting all pods together
+		if err := c.simulateBudgets(ctx, timeout, results); err != nil {
+			return nil, err
+		}
+
+		all := results
+		if !cc.allResults {
+			all = all.unevictable()
+		}
+		results = nil
+		for _, res := range all {
+			results = cc.handle(results, res)
+		}
 	}
 
-	return results, nil
+	return results, incompleteError(errs)
 }
 
 // Check eligibility of a single pod to be evicted
 func (c *Checker) checkPod(ctx context.Context, pod corev1.Pod, timeout time.Duration) (*Result, error) {
-	// check if pod has owner references
-	if len(pod.OwnerReferences) == 0 {
+	// apply kubectl drain filters
+	disposition, reason, err := c.drainDisposition(ctx, &pod, timeout)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case disposition == DispositionRefuse:
 		// get the PDBs affecting pod
-		ctx2, can := context.WithTimeout(ctx, timeout)
-		defer can()
-		pdbs, err := c.pdbLocator.PDBsForPod(ctx2, &pod)
+		pdbs, err := c.pdbsForPod(ctx, timeout, &pod)
 		if err != nil {
-			if !strings.HasPrefix(err.Error(), fmt.Sprintf("could not find PodDisruptionBudget for pod %s in namespace %s with labels: ", pod.Name, pod.Namespace)) {
-				// Don't return error if there are no PDBs affecting pod. The lister doesn't use error wrapping so we need to use string matching here
-				return nil, fmt.Errorf("error locating pod disruption budgets for pod: %w", err)
-			}
+			return nil, err
 		}
 
 		return &Result{
-			Reason:               ErrNoOwnerRefs,
+			Reason:               reason,
+			Status:               StatusUnevictable,
+			Disposition:          disposition,
 			Pod:                  pod,
 			PodDisruptionBudgets: pdbs,
 		}, nil
+	case disposition == DispositionSkip:
+		// pod is ignored by kubectl drain
+		return &Result{
+			Status:      StatusSkipped,
+			Disposition: disposition,
+			Pod:         pod,
+		}, nil
+	case disposition != DispositionEvict, isPodTerminated(&pod):
+		// pod is deleted without eviction, or has terminated and will always
+		// be allowed by the eviction API
+		return &Result{
+			Status:      StatusEvictable,
+			Disposition: disposition,
+			Pod:         pod,
+		}, nil
 	}
 
-	// create child context
-	ctx2, can := context.WithTimeout(ctx, timeout)
-	defer can()
-
-	// Run eviction dry-run
-	evictErr := c.e.DryRun(ctx2, pod)
+	// check whether the eviction API would allow pod to be evicted
+	evictErr := c.evict(ctx, timeout, &pod)
 
 	if evictErr == nil {
-		// no error, return nil
-		return nil, nil
+		// no error, pod is evictable
+		return &Result{
+			Status:      StatusEvictable,
+			Disposition: disposition,
+			Pod:         pod,
+		}, nil
 	} else if !evictor.IsUnevictableError(evictErr) {
 		// unexpected error
 		return nil, evictErr
@@ -142,16 +366,73 @@ func (c *Checker) checkPod(ctx context.Context, pod corev1.Pod, timeout time.Dur
 	// if we got here, the pod is unevictable
 
 	// get the PDBs affecting pod
-	ctx2, can = context.WithTimeout(ctx, timeout)
-	defer can()
-	pdbs, err := c.pdbLocator.PDBsForPod(ctx2, &pod)
+	pdbs, err := c.pdbsForPod(ctx, timeout, &pod)
 	if err != nil {
-		return nil, fmt.Errorf("error locating pod disruption budgets for pod: %w", err)
+		return nil, err
 	}
 
 	return &Result{
 		Reason:               evictErr,
+		Status:               StatusUnevictable,
+		Message:              evictor.MessageFor(evictErr),
+		BlockingPDB:          evictor.PDBNameFor(evictErr),
+		Disposition:          disposition,
 		Pod:                  pod,
 		PodDisruptionBudgets: pdbs,
 	}, nil
 }
+
+// Call the API server, retrying transient errors. The timeout applies to the
+// call including any retries.
+func (c *Checker) retry(ctx context.Context, timeout time.Duration, fn func(ctx context.Context) error) error {
+	ctx2, can := context.WithTimeout(ctx, timeout)
+	defer can()
+
+	return retry.Do(ctx2, c.backoff, retry.IsTransient, func() error {
+		return fn(ctx2)
+	})
+}
+
+// Check whether the eviction API would allow a pod to be evicted, either with an
+// eviction dry-run or by evaluating cached pod disruption budgets
+func (c *Checker) evict(ctx context.Context, timeout time.Duration, pod *corev1.Pod) error {
+	if c.offline {
+		pdbs, err := c.pdbsForPod(ctx, timeout, pod)
+		if err != nil {
+			return err
+		}
+		return evictor.Evaluate(pod, pdbs)
+	}
+
+	// create child context
+	ctx2, can := context.WithTimeout(ctx, timeout)
+	defer can()
+
+	// Run eviction dry-run
+	return c.e.DryRun(ctx2, *pod)
+}
+
+// Get the PDBs affecting a pod. No PDBs affecting the pod is not an error.
+func (c *Checker) pdbsForPod(ctx context.Context, timeout time.Duration, pod *corev1.Pod) ([]*policyv1.PodDisruptionBudget, error) {
+	ctx2, can := context.WithTimeout(ctx, timeout)
+	defer can()
+	pdbs, err := c.pdbLocator.PDBsForPod(ctx2, pod)
+	if err != nil {
+		return nil, fmt.Errorf("error locating pod disruption budgets for pod: %w", err)
+	}
+
+	return pdbs, nil
+}
+
+// Get results for pods that cannot be evicted
+func (r Results) unevictable() Results {
+	var out Results
+
+	for _, res := range r {
+		if res.Reason != nil {
+			out = append(out, res)
+		}
+	}
+
+	return out
+}
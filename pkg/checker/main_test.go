@@ -0,0 +1,321 @@
+package checker
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/fhke/kubectl-draincheck/pkg/evictor"
+	"github.com/fhke/kubectl-draincheck/pkg/policy"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+// Create a fake clientset that applies field selectors when listing pods, as the
+// API server does. The fake object tracker only applies label selectors.
+func newFakeClientset(objs ...runtime.Object) *fake.Clientset {
+	cs := fake.NewSimpleClientset(objs...)
+	cs.PrependReactor("list", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		la := action.(k8stesting.ListAction)
+		obj, err := cs.Tracker().List(corev1.SchemeGroupVersion.WithResource("pods"), corev1.SchemeGroupVersion.WithKind("Pod"), la.GetNamespace())
+		if err != nil {
+			return true, nil, err
+		}
+
+		restrictions := la.GetListRestrictions()
+		out := &corev1.PodList{}
+		for _, pod := range obj.(*corev1.PodList).Items {
+			if restrictions.Labels.Matches(labels.Set(pod.Labels)) && restrictions.Fields.Matches(podFields(&pod)) {
+				out.Items = append(out.Items, pod)
+			}
+		}
+		return true, out, nil
+	})
+
+	return cs
+}
+
+// Create a checker for a fake clientset, evaluating pod disruption budgets offline.
+// Pods without controllers are allowed, unless drain options are overridden.
+func newFakeChecker(t *testing.T, cs *fake.Clientset, opts ...Option) *Checker {
+	opts = append([]Option{
+		WithOfflineEvaluation(),
+		WithDrainOptions(DrainOptions{Force: true}),
+		WithPolicyVersion(policy.V1),
+	}, opts...)
+	c, err := NewCheckerForEvictor(context.Background(), cs, nil, opts...)
+	require.NoError(t, err, "Creating checker should not return error")
+	t.Cleanup(c.Stop)
+
+	return c
+}
+
+// Create a running pod in the default namespace, scheduled to node
+func newRunningPod(name, node string, labels map[string]string) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name, Labels: labels},
+		Spec:       corev1.PodSpec{NodeName: node},
+		Status:     corev1.PodStatus{Phase: corev1.PodRunning},
+	}
+}
+
+// Get the names of pods in results, in order
+func podNames(res Results) []string {
+	names := make([]string, len(res))
+	for i := range res {
+		names[i] = res[i].Pod.Name
+	}
+
+	return names
+}
+
+func TestNode(t *testing.T) {
+	t.Parallel()
+
+	blocked := map[string]string{"app": "blocked"}
+	c := newFakeChecker(t, newFakeClientset(
+		newRunningPod("a", "node-1", blocked),
+		newRunningPod("b", "node-2", blocked),
+		newRunningPod("c", "node-1", map[string]string{"app": "free"}),
+		&policyv1.PodDisruptionBudget{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "blocked"},
+			Spec:       policyv1.PodDisruptionBudgetSpec{Selector: &metav1.LabelSelector{MatchLabels: blocked}},
+		},
+	))
+
+	res, err := c.Node(context.Background(), "node-1", time.Second, 2)
+	require.NoError(t, err, "Checking node should not return error")
+	assert.Equal(t, []string{"a"}, podNames(res), "Only unevictable pods on node should be returned")
+
+	res, err = c.Node(context.Background(), "node-1", time.Second, 2, WithAllResults())
+	require.NoError(t, err, "Checking node should not return error")
+	assert.ElementsMatch(t, []string{"a", "c"}, podNames(res), "All pods on node should be returned")
+
+	res, err = c.Node(context.Background(), "node-3", time.Second, 2)
+	require.NoError(t, err, "Checking node without pods should not return error")
+	assert.Empty(t, res, "Node without pods should have no results")
+}
+
+func TestNodesBySelector(t *testing.T) {
+	t.Parallel()
+
+	newNode := func(name, pool string) *corev1.Node {
+		return &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: map[string]string{"pool": pool}}}
+	}
+	blocked := map[string]string{"app": "blocked"}
+	c := newFakeChecker(t, newFakeClientset(
+		newNode("node-b", "x"),
+		newNode("node-a", "x"),
+		newNode("node-c", "y"),
+		newRunningPod("on-b", "node-b", blocked),
+		newRunningPod("on-a", "node-a", blocked),
+		newRunningPod("on-c", "node-c", blocked),
+		&policyv1.PodDisruptionBudget{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "blocked"},
+			Spec:       policyv1.PodDisruptionBudgetSpec{Selector: &metav1.LabelSelector{MatchLabels: blocked}},
+		},
+	))
+
+	res, err := c.NodesBySelector(context.Background(), "pool=x", time.Second, 2)
+	require.NoError(t, err, "Checking nodes by selector should not return error")
+	assert.Equal(t, []string{"on-a", "on-b"}, podNames(res), "Results should only include matching nodes, sorted by node")
+
+	res, err = c.NodesBySelector(context.Background(), "pool=z", time.Second, 2)
+	require.NoError(t, err, "Checking nodes by selector without matches should not return error")
+	assert.Empty(t, res, "No nodes should match selector")
+}
+
+func TestAllResults(t *testing.T) {
+	t.Parallel()
+
+	newPod := func(name, app string, annotations map[string]string) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name, Labels: map[string]string{"app": app}, Annotations: annotations},
+			Status:     corev1.PodStatus{Phase: corev1.PodRunning},
+		}
+	}
+	newPDB := func(app string, allowed int32) *policyv1.PodDisruptionBudget {
+		return &policyv1.PodDisruptionBudget{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: app},
+			Spec:       policyv1.PodDisruptionBudgetSpec{Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": app}}},
+			Status:     policyv1.PodDisruptionBudgetStatus{DisruptionsAllowed: allowed},
+		}
+	}
+
+	cs := fake.NewSimpleClientset(
+		newPod("evictable", "foo", nil),
+		newPod("unevictable", "bar", nil),
+		newPod("skipped", "foo", map[string]string{corev1.MirrorPodAnnotationKey: "mirror"}),
+		newPDB("foo", 1),
+		newPDB("bar", 0),
+	)
+	c, err := NewCheckerForEvictor(
+		context.Background(),
+		cs,
+		nil,
+		WithOfflineEvaluation(),
+		WithDrainOptions(DrainOptions{Force: true}),
+		WithPolicyVersion(policy.V1),
+	)
+	require.NoError(t, err, "Creating checker should not return error")
+	defer c.Stop()
+
+	res, err := c.AllPods(context.Background(), "", time.Second, 2)
+	require.NoError(t, err, "Checking pods should not return error")
+	if assert.Len(t, res, 1, "Only unevictable pods should be returned by default") {
+		assert.Equal(t, StatusUnevictable, res[0].Status)
+	}
+
+	res, err = c.AllPods(context.Background(), "", time.Second, 2, WithAllResults())
+	require.NoError(t, err, "Checking all pods should not return error")
+	require.Len(t, res, 3, "All pods should be returned")
+
+	byName := make(map[string]Result, len(res))
+	for _, r := range res {
+		byName[r.Pod.Name] = r
+	}
+	assert.Equal(t, StatusEvictable, byName["evictable"].Status)
+	assert.Equal(t, StatusUnevictable, byName["unevictable"].Status)
+	assert.Equal(t, "bar", byName["unevictable"].BlockingPDB, "Blocking PDB should be named")
+	assert.Equal(t, StatusSkipped, byName["skipped"].Status)
+	for name, r := range byName {
+		if assert.Lenf(t, r.PodDisruptionBudgets, 1, "Pod %s should have its pod disruption budget", name) {
+			assert.Equal(t, r.Pod.Labels["app"], r.PodDisruptionBudgets[0].Name)
+		}
+	}
+}
+
+func TestPodsByNameIncomplete(t *testing.T) {
+	t.Parallel()
+
+	blocked := map[string]string{"app": "blocked"}
+	c := newFakeChecker(t, newFakeClientset(
+		newRunningPod("a", "node-1", blocked),
+		&policyv1.PodDisruptionBudget{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "blocked"},
+			Spec:       policyv1.PodDisruptionBudgetSpec{Selector: &metav1.LabelSelector{MatchLabels: blocked}},
+		},
+	))
+
+	res, err := c.PodsByName(context.Background(), time.Second, "default", 2, []string{"missing", "a"})
+	ie := (*IncompleteError)(nil)
+	require.ErrorAs(t, err, &ie, "Missing pods should make results incomplete")
+	assert.Len(t, ie.Errors, 1, "Only the missing pod should fail")
+
+	if assert.Len(t, res, 2, "Missing pod should be reported alongside checked pods") {
+		assert.Equal(t, "missing", res[0].Pod.Name)
+		assert.Equal(t, StatusError, res[0].Status)
+		assert.Error(t, res[0].Reason)
+		assert.Equal(t, "a", res[1].Pod.Name)
+		assert.Equal(t, StatusUnevictable, res[1].Status)
+	}
+}
+
+func TestNodesIncomplete(t *testing.T) {
+	t.Parallel()
+
+	controller := true
+	newDaemonSetPod := func(name, node string) *corev1.Pod {
+		pod := newRunningPod(name, node, nil)
+		pod.OwnerReferences = []metav1.OwnerReference{{Kind: "DaemonSet", Name: "ds", Controller: &controller}}
+		return pod
+	}
+
+	// fail to get daemonsets, so that daemonset pods cannot be checked
+	cs := newFakeClientset(
+		newDaemonSetPod("a", "node-1"),
+		newDaemonSetPod("b", "node-2"),
+		newRunningPod("c", "node-2", nil),
+	)
+	cs.PrependReactor("get", "daemonsets", func(k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, kerrors.NewForbidden(schema.GroupResource{Group: "apps", Resource: "daemonsets"}, "ds", errors.New("forbidden"))
+	})
+	c := newFakeChecker(t, cs)
+
+	res, err := c.Nodes(context.Background(), []string{"node-1", "node-2"}, time.Second, 2, WithAllResults())
+	ie := (*IncompleteError)(nil)
+	require.ErrorAs(t, err, &ie, "Pods that could not be checked should make results incomplete")
+	assert.Len(t, ie.Errors, 2, "Errors should be aggregated across nodes")
+	assert.Contains(t, err.Error(), "some pods could not be checked")
+
+	statuses := make(map[string]Status, len(res))
+	for _, r := range res {
+		statuses[r.Pod.Name] = r.Status
+	}
+	assert.Equal(t, map[string]Status{"a": StatusError, "b": StatusError, "c": StatusEvictable}, statuses, "Other pods should still be checked")
+}
+
+// Evictor that runs dry-runs with a function
+type funcEvictor func(ctx context.Context, pod corev1.Pod) error
+
+func (f funcEvictor) DryRun(ctx context.Context, pod corev1.Pod) error {
+	return f(ctx, pod)
+}
+
+func TestOnResult(t *testing.T) {
+	t.Parallel()
+
+	objs := []runtime.Object{newRunningPod("first", "node-1", nil)}
+	for i := 0; i < 20; i++ {
+		objs = append(objs, newRunningPod("pod-"+strconv.Itoa(i), "node-1", nil))
+	}
+
+	// block dry-runs for other pods until the first result has been handled, so that
+	// the run can only end if results are handled as soon as they are ready
+	first := make(chan struct{})
+	e := funcEvictor(func(ctx context.Context, pod corev1.Pod) error {
+		if pod.Name != "first" {
+			select {
+			case <-first:
+			case <-time.After(time.Second * 5):
+				return errors.New("result was not handled before the run ended")
+			}
+		}
+		return &evictor.EvictionError{Reason: evictor.ErrNoDisruptions}
+	})
+	c, err := NewCheckerForEvictor(context.Background(), newFakeClientset(objs...), e,
+		WithDrainOptions(DrainOptions{Force: true}),
+		WithPolicyVersion(policy.V1),
+	)
+	require.NoError(t, err, "Creating checker should not return error")
+	defer c.Stop()
+
+	var (
+		active     int32
+		concurrent bool
+		handled    Results
+	)
+	res, err := c.AllPods(context.Background(), "", time.Second*10, 4, OnResult(func(r Result) {
+		if atomic.AddInt32(&active, 1) > 1 {
+			concurrent = true
+		}
+		defer atomic.AddInt32(&active, -1)
+
+		// give other workers a chance to call the handler concurrently
+		time.Sleep(time.Millisecond)
+		handled = append(handled, r)
+		if r.Pod.Name == "first" {
+			close(first)
+		}
+	}))
+	require.NoError(t, err, "Checking pods should not return error")
+
+	assert.Empty(t, res, "Handled results should not also be returned")
+	assert.Len(t, handled, len(objs), "Every result should be handled")
+	assert.False(t, concurrent, "Result handler should never be called concurrently")
+	for _, r := range handled {
+		assert.Equal(t, StatusUnevictable, r.Status, "Pod %s should be unevictable", r.Pod.Name)
+	}
+}
@@ -0,0 +1,63 @@
+package checker
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Get a function that checks whether pods in a namespace should be checked, from the
+// namespace patterns & selector. Namespaces matching the selector are listed once.
+func (c *Checker) namespaceFilter(ctx context.Context, timeout time.Duration, cc *checkConfig) (func(string) bool, error) {
+	// validate patterns
+	for _, pattern := range append(cc.namespaces, cc.excludedNamespaces...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid namespace pattern %q: %w", pattern, err)
+		}
+	}
+
+	// list namespaces matching selector
+	var selected map[string]bool
+	if cc.namespaceSelector != "" {
+		var nsList *corev1.NamespaceList
+		err := c.retry(ctx, timeout, func(ctx context.Context) (err error) {
+			nsList, err = c.k.CoreV1().Namespaces().List(ctx, metav1.ListOptions{
+				LabelSelector: cc.namespaceSelector,
+			})
+			return err
+		})
+		if err != nil {
+			return nil, fmt.Errorf("error listing namespaces: %w", err)
+		}
+
+		selected = make(map[string]bool, len(nsList.Items))
+		for _, ns := range nsList.Items {
+			selected[ns.Name] = true
+		}
+	}
+
+	return func(namespace string) bool {
+		if selected != nil && !selected[namespace] {
+			return false
+		}
+		if len(cc.namespaces) > 0 && !matchesAny(cc.namespaces, namespace) {
+			return false
+		}
+		return !matchesAny(cc.excludedNamespaces, namespace)
+	}, nil
+}
+
+// Check whether a namespace matches any glob pattern. Patterns must be valid.
+func matchesAny(patterns []string, namespace string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, namespace); ok {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,51 @@
+package checker
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestNamespaceFilter(t *testing.T) {
+	t.Parallel()
+
+	cs := fake.NewSimpleClientset(
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team-a", Labels: map[string]string{"tier": "prod"}}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team-b"}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team-c", Labels: map[string]string{"tier": "prod"}}},
+	)
+	c := newChecker(cs, nil)
+	ctx := context.Background()
+
+	all, err := c.namespaceFilter(ctx, time.Second, newCheckConfig())
+	require.NoError(t, err, "Filter without patterns should not return error")
+	assert.True(t, all("kube-system"), "Filter without patterns should include all namespaces")
+
+	patterns, err := c.namespaceFilter(ctx, time.Second, newCheckConfig(
+		WithNamespaces("team-*", "default"),
+		WithExcludedNamespaces("*-b"),
+	))
+	require.NoError(t, err, "Filter with patterns should not return error")
+	assert.True(t, patterns("team-a"), "Namespace matching pattern should be included")
+	assert.True(t, patterns("default"), "Namespace matching literal pattern should be included")
+	assert.False(t, patterns("team-b"), "Excluded namespace should not be included")
+	assert.False(t, patterns("kube-system"), "Namespace not matching any pattern should not be included")
+
+	selected, err := c.namespaceFilter(ctx, time.Second, newCheckConfig(
+		WithNamespaceSelector("tier=prod"),
+		WithExcludedNamespaces("team-c"),
+	))
+	require.NoError(t, err, "Filter with selector should not return error")
+	assert.True(t, selected("team-a"), "Namespace matching selector should be included")
+	assert.False(t, selected("team-b"), "Namespace not matching selector should not be included")
+	assert.False(t, selected("team-c"), "Excluded namespace matching selector should not be included")
+
+	_, err = c.namespaceFilter(ctx, time.Second, newCheckConfig(WithExcludedNamespaces("team-[")))
+	assert.Error(t, err, "Invalid pattern should return error")
+}
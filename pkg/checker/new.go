@@ -6,28 +6,143 @@ import (
 
 	"github.com/fhke/kubectl-draincheck/pkg/evictor"
 	"github.com/fhke/kubectl-draincheck/pkg/locator"
+	"github.com/fhke/kubectl-draincheck/pkg/policy"
+	"github.com/fhke/kubectl-draincheck/pkg/retry"
 	"k8s.io/client-go/kubernetes"
 )
 
 // instantiate a new Checker{} from a clientset
-func NewChecker(ctx context.Context, clientset kubernetes.Interface) (*Checker, error) {
-	// create an Evictor
-	e := evictor.NewEvictor(clientset)
+func NewChecker(ctx context.Context, clientset kubernetes.Interface, opts ...Option) (*Checker, error) {
+	c := newChecker(clientset, nil, opts...)
 
-	return NewCheckerForEvictor(ctx, clientset, e)
+	// discover policy API version once for both the evictor & locator, if not set
+	if c.policyVersion == "" {
+		v, err := policy.Version(clientset.Discovery())
+		if err != nil {
+			return nil, fmt.Errorf("error discovering policy API version: %w", err)
+		}
+		opts = append(opts, WithPolicyVersion(v))
+		c.policyVersion = v
+	}
+
+	// create an Evictor that retries in the same way as the checker
+	e := evictor.NewEvictor(
+		clientset,
+		evictor.WithRetry(c.backoff),
+		evictor.WithPolicyVersion(c.policyVersion),
+	)
+
+	return NewCheckerForEvictor(ctx, clientset, e, opts...)
 }
 
 // instantiate a new Checker{} from a clientset & Evictor
-func NewCheckerForEvictor(ctx context.Context, clientset kubernetes.Interface, e evictor.Evictor) (*Checker, error) {
+func NewCheckerForEvictor(ctx context.Context, clientset kubernetes.Interface, e evictor.Evictor, opts ...Option) (*Checker, error) {
+	c := newChecker(clientset, e, opts...)
+
 	// create pdb locator
-	l, err := locator.NewPDBLocator(ctx, clientset)
+	locatorOpts := []locator.Option{
+		locator.WithSelectorIndex(),
+		locator.WithNamespace(c.namespace),
+	}
+	if c.cachePods {
+		locatorOpts = append(locatorOpts, locator.WithPodInformer())
+	}
+	if c.policyVersion != "" {
+		locatorOpts = append(locatorOpts, locator.WithPolicyVersion(c.policyVersion))
+	}
+	if c.onWatchError != nil {
+		locatorOpts = append(locatorOpts, locator.WithWatchErrorHandler(c.onWatchError))
+	}
+	l, err := locator.NewPDBLocator(ctx, clientset, locatorOpts...)
 	if err != nil {
 		return nil, fmt.Errorf("error creating pod disruption budget locator: %w", err)
 	}
+	c.pdbLocator = l
+
+	return c, nil
+}
+
+// instantiate a Checker{} & apply options, without creating a locator
+func newChecker(clientset kubernetes.Interface, e evictor.Evictor, opts ...Option) *Checker {
+	c := &Checker{
+		k:        clientset,
+		e:        e,
+		backoff:  retry.NoRetries,
+		pageSize: DefaultPageSize,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
+}
+
+// Decide which pods to remove in the same way as kubectl drain with the given flags
+func WithDrainOptions(o DrainOptions) Option {
+	return func(c *Checker) {
+		c.drainOptions = o
+	}
+}
+
+// Evaluate evictability from cached pod disruption budgets instead of running eviction
+// dry-runs. This only needs read access to pods & pod disruption budgets.
+func WithOfflineEvaluation() Option {
+	return func(c *Checker) {
+		c.offline = true
+	}
+}
+
+// Retry calls to the API server that fail with transient errors, e.g. throttling
+func WithRetry(b retry.Backoff) Option {
+	return func(c *Checker) {
+		c.backoff = b
+	}
+}
+
+// Set the number of pods to request from the API server per page
+func WithPageSize(n int64) Option {
+	return func(c *Checker) {
+		c.pageSize = n
+	}
+}
+
+// Cache pods with an informer, so that repeated checks by a long-lived Checker
+// are answered from the cache rather than by listing pods from the API server
+func WithPodCache() Option {
+	return func(c *Checker) {
+		c.cachePods = true
+	}
+}
 
-	return &Checker{
-		k:          clientset,
-		e:          e,
-		pdbLocator: l,
-	}, nil
+// Only check pods in a namespace. Pod disruption budgets are watched in the namespace
+// only, so namespace-scoped permissions are enough. Checking pods in other namespaces,
+// or on nodes, returns ErrOtherNamespace.
+func WithNamespace(namespace string) Option {
+	return func(c *Checker) {
+		c.namespace = namespace
+	}
+}
+
+// Call a function when an informer's watch fails. Long-lived Checkers may answer
+// from stale caches until the watch recovers.
+func WithWatchErrorHandler(fn func(resource string, err error)) Option {
+	return func(c *Checker) {
+		c.onWatchError = fn
+	}
+}
+
+// Set the policy API version for evictions & pod disruption budgets, instead of
+// discovering it, e.g. policy.V1beta1 for clusters older than 1.21
+func WithPolicyVersion(version string) Option {
+	return func(c *Checker) {
+		c.policyVersion = version
+	}
+}
+
+// Set the name of the cluster that the checker checks, e.g. the kubeconfig context.
+// The name is set on every result, so that results from multiple clusters can be merged.
+func WithCluster(name string) Option {
+	return func(c *Checker) {
+		c.cluster = name
+	}
 }
@@ -0,0 +1,105 @@
+package checker
+
+import (
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Only check pods matching a label selector
+func WithLabelSelector(selector string) CheckOption {
+	return func(cc *checkConfig) {
+		cc.labelSelector = selector
+	}
+}
+
+// Only check pods matching a field selector
+func WithFieldSelector(selector string) CheckOption {
+	return func(cc *checkConfig) {
+		cc.fieldSelector = selector
+	}
+}
+
+// Only check pods in namespaces matching any of the glob patterns, e.g. team-*
+func WithNamespaces(patterns ...string) CheckOption {
+	return func(cc *checkConfig) {
+		cc.namespaces = append(cc.namespaces, patterns...)
+	}
+}
+
+// Don't check pods in namespaces matching any of the glob patterns, e.g. ci-*
+func WithExcludedNamespaces(patterns ...string) CheckOption {
+	return func(cc *checkConfig) {
+		cc.excludedNamespaces = append(cc.excludedNamespaces, patterns...)
+	}
+}
+
+// Only check pods in namespaces matching a label selector. This needs permission
+// to list namespaces.
+func WithNamespaceSelector(selector string) CheckOption {
+	return func(cc *checkConfig) {
+		cc.namespaceSelector = selector
+	}
+}
+
+// Simulate evicting all selected pods together, as kubectl drain does for the
+// pods on a node. Pods that would be evictable on their own are reported with
+// ErrBudgetExhausted if earlier evictions use up the disruption budget of a
+// pod disruption budget that they share.
+func WithBudgetSimulation() CheckOption {
+	return func(cc *checkConfig) {
+		cc.simulateBudgets = true
+	}
+}
+
+// Return results for every selected pod, with a status of StatusEvictable,
+// StatusUnevictable, StatusSkipped or StatusError, instead of only returning
+// results for pods that cannot be evicted. Results include the pod disruption
+// budgets covering each pod.
+func WithAllResults() CheckOption {
+	return func(cc *checkConfig) {
+		cc.allResults = true
+	}
+}
+
+// Pass each result to fn as soon as it is ready, instead of returning results once
+// all pods have been checked. fn is never called concurrently. When budgets are
+// simulated, results are passed to fn once the simulation is complete.
+func OnResult(fn func(Result)) CheckOption {
+	return func(cc *checkConfig) {
+		cc.onResult = fn
+	}
+}
+
+// build config from options
+func newCheckConfig(opts ...CheckOption) *checkConfig {
+	cc := &checkConfig{}
+	for _, opt := range opts {
+		opt(cc)
+	}
+
+	return cc
+}
+
+// Get list options for selecting pods. Any extra field selectors are ANDed
+// with the configured field selector.
+func (cc *checkConfig) listOptions(fieldSelectors ...string) metav1.ListOptions {
+	if cc.fieldSelector != "" {
+		fieldSelectors = append(fieldSelectors, cc.fieldSelector)
+	}
+
+	return metav1.ListOptions{
+		LabelSelector: cc.labelSelector,
+		FieldSelector: strings.Join(fieldSelectors, ","),
+	}
+}
+
+// Pass a result to the result handler, or append it to results if there is no handler
+func (cc *checkConfig) handle(results Results, res Result) Results {
+	if cc.onResult != nil {
+		cc.onResult(res)
+		return results
+	}
+
+	return append(results, res)
+}
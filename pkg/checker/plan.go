@@ -0,0 +1,227 @@
+package checker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/fhke/kubectl-draincheck/pkg/evictor"
+	policyv1 "k8s.io/api/policy/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+var ErrBudgetExceeded = errors.New("draining node would use more disruptions than pod disruption budget allows")
+
+// Plan the order in which to drain nodes. Nodes are grouped into batches that can be
+// drained in parallel without any pod disruption budget blocking evictions, assuming
+// that evicted pods are healthy again before the next batch is drained.
+func (c *Checker) PlanNodes(ctx context.Context, timeout time.Duration, nodeNames ...string) (*Plan, error) {
+	var (
+		usages = make([]nodeUsage, 0, len(nodeNames))
+		pdbs   = make(map[types.NamespacedName]*policyv1.PodDisruptionBudget)
+	)
+
+	// find the disruptions that draining each node would use
+	for _, nodeName := range nodeNames {
+		u, err := c.nodeUsage(ctx, timeout, nodeName, pdbs)
+		if err != nil {
+			return nil, err
+		}
+		usages = append(usages, u)
+	}
+
+	return buildPlan(usages, pdbs), nil
+}
+
+// Find the disruptions that draining a node would use for each pod disruption budget.
+// Pod disruption budgets acting on pods are added to pdbs.
+func (c *Checker) nodeUsage(ctx context.Context, timeout time.Duration, nodeName string, pdbs map[types.NamespacedName]*policyv1.PodDisruptionBudget) (nodeUsage, error) {
+	u := nodeUsage{
+		node:        nodeName,
+		disruptions: make(map[types.NamespacedName]int32),
+	}
+
+	// list pods scheduled to node in all namespaces
+	pods, err := c.listPods(ctx, "", timeout, metav1.ListOptions{
+		FieldSelector: fields.OneTermEqualSelector("spec.nodeName", nodeName).String(),
+	})
+	if err != nil {
+		return u, err
+	}
+
+	for i := range pods {
+		pod := &pods[i]
+
+		// apply kubectl drain filters, in the same way as checks
+		disposition, reason, err := c.drainDisposition(ctx, pod, timeout)
+		if err != nil {
+			return u, err
+		}
+		if disposition == DispositionRefuse {
+			// kubectl drain fails before evicting any pods
+			if u.refused == nil {
+				u.refused = fmt.Errorf("kubectl drain would refuse to remove pod %s/%s: %w", pod.Namespace, pod.Name, reason)
+			}
+			continue
+		}
+
+		// Pods that are skipped or deleted without eviction don't use any budget, and the
+		// eviction API ignores pod disruption budgets for pods that are not running
+		if disposition != DispositionEvict || evictor.CanIgnorePDB(pod) {
+			continue
+		}
+
+		podPDBs, err := c.pdbsForPod(ctx, timeout, pod)
+		if err != nil {
+			return u, err
+		}
+
+		if len(podPDBs) > 1 {
+			// the eviction API rejects pods with multiple PDBs
+			for _, pdb := range podPDBs {
+				if !containsPDB(u.multiplePDBs, pdb) {
+					u.multiplePDBs = append(u.multiplePDBs, pdb)
+				}
+			}
+			continue
+		}
+
+		for _, pdb := range podPDBs {
+			// the eviction API may allow pods that are not ready to be evicted
+			// without using any budget
+			if evictor.EvictsWithoutBudget(pod, pdb, pdb.Status.CurrentHealthy) {
+				continue
+			}
+
+			key := types.NamespacedName{Namespace: pdb.Namespace, Name: pdb.Name}
+			pdbs[key] = pdb
+			u.disruptions[key]++
+		}
+	}
+
+	return u, nil
+}
+
+// Group nodes into batches using first-fit decreasing, so that nodes using the most
+// disruptions are placed first
+func buildPlan(usages []nodeUsage, pdbs map[types.NamespacedName]*policyv1.PodDisruptionBudget) *Plan {
+	sort.SliceStable(usages, func(i, j int) bool {
+		ti, tj := usages[i].total(), usages[j].total()
+		if ti != tj {
+			return ti > tj
+		}
+		return usages[i].node < usages[j].node
+	})
+
+	var (
+		plan = &Plan{
+			Batches: []Batch{},
+			Blocked: []BlockedNode{},
+		}
+		// disruptions used by each batch
+		batchUsage []map[types.NamespacedName]int32
+	)
+
+	for _, u := range usages {
+		// check whether node can be drained at all
+		if u.refused != nil {
+			plan.Blocked = append(plan.Blocked, BlockedNode{
+				Node:   u.node,
+				Reason: u.refused,
+			})
+			continue
+		}
+		if len(u.multiplePDBs) > 0 {
+			plan.Blocked = append(plan.Blocked, BlockedNode{
+				Node:                 u.node,
+				Reason:               evictor.ErrTooManyPDBs,
+				PodDisruptionBudgets: u.multiplePDBs,
+			})
+			continue
+		}
+		if exceeded := u.exceeds(nil, pdbs); len(exceeded) > 0 {
+			plan.Blocked = append(plan.Blocked, BlockedNode{
+				Node:                 u.node,
+				Reason:               ErrBudgetExceeded,
+				PodDisruptionBudgets: exceeded,
+			})
+			continue
+		}
+
+		// add node to the first batch it fits in
+		placed := false
+		for i := range plan.Batches {
+			if len(u.exceeds(batchUsage[i], pdbs)) == 0 {
+				plan.Batches[i].Nodes = append(plan.Batches[i].Nodes, u.node)
+				for key, n := range u.disruptions {
+					batchUsage[i][key] += n
+				}
+				placed = true
+				break
+			}
+		}
+
+		// otherwise start a new batch
+		if !placed {
+			plan.Batches = append(plan.Batches, Batch{Nodes: []string{u.node}})
+			used := make(map[types.NamespacedName]int32, len(u.disruptions))
+			for key, n := range u.disruptions {
+				used[key] = n
+			}
+			batchUsage = append(batchUsage, used)
+		}
+	}
+
+	// sort nodes within batches by name
+	for i := range plan.Batches {
+		sort.Strings(plan.Batches[i].Nodes)
+	}
+
+	return plan
+}
+
+// Get the pod disruption budgets that would block evictions if the node was drained
+// at the same time as nodes that have already used disruptions
+func (u nodeUsage) exceeds(used map[types.NamespacedName]int32, pdbs map[types.NamespacedName]*policyv1.PodDisruptionBudget) []*policyv1.PodDisruptionBudget {
+	var out []*policyv1.PodDisruptionBudget
+
+	for key, n := range u.disruptions {
+		if used[key]+n > pdbs[key].Status.DisruptionsAllowed {
+			out = append(out, pdbs[key])
+		}
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Namespace != out[j].Namespace {
+			return out[i].Namespace < out[j].Namespace
+		}
+		return out[i].Name < out[j].Name
+	})
+
+	return out
+}
+
+// Get the total number of disruptions used by draining a node
+func (u nodeUsage) total() int32 {
+	var total int32
+	for _, n := range u.disruptions {
+		total += n
+	}
+
+	return total
+}
+
+// check whether a slice contains a pod disruption budget
+func containsPDB(pdbs []*policyv1.PodDisruptionBudget, pdb *policyv1.PodDisruptionBudget) bool {
+	for _, p := range pdbs {
+		if p.Namespace == pdb.Namespace && p.Name == pdb.Name {
+			return true
+		}
+	}
+
+	return false
+}
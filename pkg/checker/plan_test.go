@@ -0,0 +1,172 @@
+package checker
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/fhke/kubectl-draincheck/pkg/evictor"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestBuildPlan(t *testing.T) {
+	t.Parallel()
+
+	var (
+		one  = types.NamespacedName{Namespace: "default", Name: "one"}
+		two  = types.NamespacedName{Namespace: "default", Name: "two"}
+		pdbs = map[types.NamespacedName]*policyv1.PodDisruptionBudget{
+			one: newTestPDB(one, 1),
+			two: newTestPDB(two, 2),
+		}
+	)
+
+	plan := buildPlan(
+		[]nodeUsage{
+			{node: "a", disruptions: map[types.NamespacedName]int32{one: 1}},
+			{node: "b", disruptions: map[types.NamespacedName]int32{one: 1, two: 1}},
+			{node: "c", disruptions: map[types.NamespacedName]int32{two: 1}},
+			{node: "d", disruptions: map[types.NamespacedName]int32{}},
+			{node: "e", disruptions: map[types.NamespacedName]int32{two: 3}},
+			{node: "f", multiplePDBs: []*policyv1.PodDisruptionBudget{pdbs[one], pdbs[two]}},
+			{node: "g", refused: ErrLocalStorage},
+		},
+		pdbs,
+	)
+
+	assert.Equal(
+		t,
+		[]Batch{
+			{Nodes: []string{"b", "c", "d"}},
+			{Nodes: []string{"a"}},
+		},
+		plan.Batches,
+		"Nodes sharing a budget should be split into separate batches",
+	)
+
+	if assert.Len(t, plan.Blocked, 3) {
+		assert.Equal(t, "e", plan.Blocked[0].Node)
+		assert.Equal(t, ErrBudgetExceeded, plan.Blocked[0].Reason)
+		assert.Equal(t, "f", plan.Blocked[1].Node)
+		assert.Equal(t, evictor.ErrTooManyPDBs, plan.Blocked[1].Reason)
+		assert.Equal(t, "g", plan.Blocked[2].Node)
+		assert.Equal(t, ErrLocalStorage, plan.Blocked[2].Reason)
+	}
+}
+
+func TestPlanNodesDrainFilters(t *testing.T) {
+	t.Parallel()
+
+	var (
+		controller = true
+		managed    = []metav1.OwnerReference{{Kind: "ReplicaSet", Name: "rs", Controller: &controller}}
+		labels     = map[string]string{"app": "foo"}
+		ready      = corev1.PodStatus{
+			Phase:      corev1.PodRunning,
+			Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionTrue}},
+		}
+	)
+	newPod := func(name, node string, mutate func(*corev1.Pod)) *corev1.Pod {
+		pod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name, Labels: labels, OwnerReferences: managed},
+			Spec:       corev1.PodSpec{NodeName: node},
+			Status:     *ready.DeepCopy(),
+		}
+		mutate(pod)
+		return pod
+	}
+
+	c := newFakeChecker(t, newFakeClientset(
+		// mirror & terminated pods don't use any budget
+		newPod("mirror", "node-1", func(p *corev1.Pod) {
+			p.OwnerReferences = nil
+			p.Annotations = map[string]string{corev1.MirrorPodAnnotationKey: ""}
+		}),
+		newPod("terminated", "node-1", func(p *corev1.Pod) { p.Status.Phase = corev1.PodSucceeded }),
+		// kubectl drain refuses to remove unreplicated pods
+		newPod("unreplicated", "node-2", func(p *corev1.Pod) { p.OwnerReferences = nil }),
+		// pods using the budget block each other
+		newPod("a", "node-3", func(*corev1.Pod) {}),
+		newPod("b", "node-4", func(*corev1.Pod) {}),
+		&policyv1.PodDisruptionBudget{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "foo"},
+			Spec:       policyv1.PodDisruptionBudgetSpec{Selector: &metav1.LabelSelector{MatchLabels: labels}},
+			Status:     policyv1.PodDisruptionBudgetStatus{DisruptionsAllowed: 1},
+		},
+	), WithDrainOptions(DrainOptions{}))
+
+	plan, err := c.PlanNodes(context.Background(), time.Second, "node-1", "node-2", "node-3", "node-4")
+	require.NoError(t, err, "Planning nodes should not return error")
+
+	assert.Equal(t, []Batch{{Nodes: []string{"node-1", "node-3"}}, {Nodes: []string{"node-4"}}}, plan.Batches)
+	if assert.Len(t, plan.Blocked, 1) {
+		assert.Equal(t, "node-2", plan.Blocked[0].Node)
+		assert.ErrorIs(t, plan.Blocked[0].Reason, ErrNoOwnerRefs, "Node should be blocked by pod that drain refuses to remove")
+	}
+}
+
+func TestPlanNodesUnhealthyPods(t *testing.T) {
+	t.Parallel()
+
+	var (
+		alwaysAllow = policyv1.AlwaysAllow
+		unready     = corev1.PodStatus{
+			Phase:      corev1.PodRunning,
+			Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionFalse}},
+		}
+		pending = corev1.PodStatus{Phase: corev1.PodPending}
+	)
+	newPod := func(name, node, app string, status corev1.PodStatus) *corev1.Pod {
+		pod := newRunningPod(name, node, map[string]string{"app": app})
+		pod.Status = status
+		return pod
+	}
+	newPDB := func(app string, policy *policyv1.UnhealthyPodEvictionPolicyType, status policyv1.PodDisruptionBudgetStatus) *policyv1.PodDisruptionBudget {
+		return &policyv1.PodDisruptionBudget{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: app},
+			Spec: policyv1.PodDisruptionBudgetSpec{
+				Selector:                   &metav1.LabelSelector{MatchLabels: map[string]string{"app": app}},
+				UnhealthyPodEvictionPolicy: policy,
+			},
+			Status: status,
+		}
+	}
+
+	c := newFakeChecker(t, newFakeClientset(
+		// unready pods under an unhealthy budget use a disruption, which isn't allowed
+		newPod("unhealthy", "node-1", "unhealthy", unready),
+		newPDB("unhealthy", nil, policyv1.PodDisruptionBudgetStatus{CurrentHealthy: 0, DesiredHealthy: 1}),
+		// unready pods under a healthy budget don't use any budget
+		newPod("healthy", "node-2", "healthy", unready),
+		newPDB("healthy", nil, policyv1.PodDisruptionBudgetStatus{CurrentHealthy: 1, DesiredHealthy: 1}),
+		// unready pods are always evicted with the AlwaysAllow policy
+		newPod("always-allow", "node-3", "always-allow", unready),
+		newPDB("always-allow", &alwaysAllow, policyv1.PodDisruptionBudgetStatus{CurrentHealthy: 0, DesiredHealthy: 1}),
+		// pod disruption budgets don't apply to pending pods
+		newPod("pending", "node-4", "pending", pending),
+		newPDB("pending", nil, policyv1.PodDisruptionBudgetStatus{CurrentHealthy: 0, DesiredHealthy: 1}),
+	))
+
+	plan, err := c.PlanNodes(context.Background(), time.Second, "node-1", "node-2", "node-3", "node-4")
+	require.NoError(t, err, "Planning nodes should not return error")
+
+	assert.Equal(t, []Batch{{Nodes: []string{"node-2", "node-3", "node-4"}}}, plan.Batches)
+	if assert.Len(t, plan.Blocked, 1) {
+		assert.Equal(t, "node-1", plan.Blocked[0].Node)
+		assert.ErrorIs(t, plan.Blocked[0].Reason, ErrBudgetExceeded, "Node should be blocked by unready pod under unhealthy budget")
+	}
+}
+
+func newTestPDB(key types.NamespacedName, disruptionsAllowed int32) *policyv1.PodDisruptionBudget {
+	return &policyv1.PodDisruptionBudget{
+		ObjectMeta: metav1.ObjectMeta{Name: key.Name, Namespace: key.Namespace},
+		Status: policyv1.PodDisruptionBudgetStatus{
+			DisruptionsAllowed: disruptionsAllowed,
+		},
+	}
+}
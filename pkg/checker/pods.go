@@ -0,0 +1,188 @@
+package checker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// Default number of pods to request from the API server per page
+const DefaultPageSize = 500
+
+// Number of pages of listed pods that can wait to be checked, so that listing
+// continues while pods are checked without holding every pod in memory
+const queuedPages = 4
+
+// List pods in a namespace
+func (c *Checker) listPods(ctx context.Context, namespace string, timeout time.Duration, opts metav1.ListOptions) ([]corev1.Pod, error) {
+	var pods []corev1.Pod
+
+	err := c.pagePods(ctx, namespace, timeout, opts, func(page []corev1.Pod) {
+		pods = append(pods, page...)
+	})
+
+	return pods, err
+}
+
+// List pods in a namespace, passing each page of pods to fn. Pods are listed from the
+// cache if pods are cached, otherwise they are listed from the API server in pages.
+func (c *Checker) pagePods(ctx context.Context, namespace string, timeout time.Duration, opts metav1.ListOptions, fn func([]corev1.Pod)) error {
+	if err := c.checkNamespace(namespace); err != nil {
+		return err
+	}
+
+	if lister := c.pdbLocator.PodLister(); lister != nil {
+		pods, err := listCachedPods(lister.Pods(namespace).List, opts)
+		if err != nil {
+			return fmt.Errorf("error listing cached pods: %w", err)
+		}
+		fn(pods)
+		return nil
+	}
+
+	var (
+		// pods that have been listed, in case the list is restarted
+		listed    = make(map[types.NamespacedName]bool)
+		restarted bool
+	)
+
+	opts.Limit = c.pageSize
+	for {
+		var podList *corev1.PodList
+		err := c.retry(ctx, timeout, func(ctx context.Context) (err error) {
+			podList, err = c.k.CoreV1().Pods(namespace).List(ctx, opts)
+			return err
+		})
+		if err != nil && opts.Continue != "" && kerrors.IsResourceExpired(err) {
+			// The continue token expired before the next page was requested. Continue
+			// from the latest snapshot with the token returned with the error, or
+			// restart the list, skipping pods that have already been listed.
+			opts.Continue = expiredContinue(err)
+			restarted = restarted || opts.Continue == ""
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("error listing pods: %w", err)
+		}
+
+		page := make([]corev1.Pod, 0, len(podList.Items))
+		for _, pod := range podList.Items {
+			key := types.NamespacedName{Namespace: pod.Namespace, Name: pod.Name}
+			if !restarted || !listed[key] {
+				page = append(page, pod)
+			}
+			listed[key] = true
+		}
+		fn(page)
+
+		// stop once there are no more pages
+		if podList.Continue == "" {
+			return nil
+		}
+		opts.Continue = podList.Continue
+	}
+}
+
+// Get the continue token returned with an expired continue token error, which continues
+// the list from the latest snapshot. Returns "" if there is no token.
+func expiredContinue(err error) string {
+	status := kerrors.APIStatus(nil)
+	if !errors.As(err, &status) {
+		return ""
+	}
+
+	return status.Status().ListMeta.Continue
+}
+
+// Get a pod by name, from the cache if pods are cached
+func (c *Checker) getPod(ctx context.Context, namespace, name string, timeout time.Duration) (*corev1.Pod, error) {
+	if err := c.checkNamespace(namespace); err != nil {
+		return nil, err
+	}
+
+	if lister := c.pdbLocator.PodLister(); lister != nil {
+		return lister.Pods(namespace).Get(name)
+	}
+
+	var pod *corev1.Pod
+	err := c.retry(ctx, timeout, func(ctx context.Context) (err error) {
+		pod, err = c.k.CoreV1().Pods(namespace).Get(ctx, name, metav1.GetOptions{})
+		return err
+	})
+
+	return pod, err
+}
+
+// Return an error if the checker is scoped to a different namespace. An empty
+// namespace means all namespaces.
+func (c *Checker) checkNamespace(namespace string) error {
+	if c.namespace != "" && namespace != c.namespace {
+		return fmt.Errorf("%w: cannot check pods in %s", ErrOtherNamespace, describeNamespace(namespace))
+	}
+
+	return nil
+}
+
+func describeNamespace(namespace string) string {
+	if namespace == "" {
+		return "all namespaces"
+	}
+	return "namespace " + namespace
+}
+
+// List cached pods matching the label & field selectors in list options
+func listCachedPods(list func(labels.Selector) ([]*corev1.Pod, error), opts metav1.ListOptions) ([]corev1.Pod, error) {
+	labelSelector, err := labels.Parse(opts.LabelSelector)
+	if err != nil {
+		return nil, fmt.Errorf("invalid label selector: %w", err)
+	}
+
+	fieldSelector, err := fields.ParseSelector(opts.FieldSelector)
+	if err != nil {
+		return nil, fmt.Errorf("invalid field selector: %w", err)
+	}
+
+	// the cache can only match the fields that the API server supports for pods
+	for _, req := range fieldSelector.Requirements() {
+		if _, ok := podFields(&corev1.Pod{})[req.Field]; !ok {
+			return nil, fmt.Errorf("field label not supported: %s", req.Field)
+		}
+	}
+
+	cached, err := list(labelSelector)
+	if err != nil {
+		return nil, err
+	}
+
+	var pods []corev1.Pod
+	for _, pod := range cached {
+		if fieldSelector.Matches(podFields(pod)) {
+			pods = append(pods, *pod)
+		}
+	}
+
+	return pods, nil
+}
+
+// Get the fields of a pod that can be used in field selectors
+func podFields(pod *corev1.Pod) fields.Set {
+	return fields.Set{
+		"metadata.name":            pod.Name,
+		"metadata.namespace":       pod.Namespace,
+		"spec.nodeName":            pod.Spec.NodeName,
+		"spec.restartPolicy":       string(pod.Spec.RestartPolicy),
+		"spec.schedulerName":       pod.Spec.SchedulerName,
+		"spec.serviceAccountName":  pod.Spec.ServiceAccountName,
+		"status.phase":             string(pod.Status.Phase),
+		"status.podIP":             pod.Status.PodIP,
+		"status.nominatedNodeName": pod.Status.NominatedNodeName,
+	}
+}
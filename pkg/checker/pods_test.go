@@ -0,0 +1,336 @@
+package checker
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fhke/kubectl-draincheck/pkg/policy"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes/fake"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+)
+
+func TestListCachedPods(t *testing.T) {
+	t.Parallel()
+
+	cached := []*corev1.Pod{
+		{ObjectMeta: metav1.ObjectMeta{Name: "a", Labels: map[string]string{"app": "foo"}}, Spec: corev1.PodSpec{NodeName: "node-1"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "b", Labels: map[string]string{"app": "foo"}}, Spec: corev1.PodSpec{NodeName: "node-2"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "c", Labels: map[string]string{"app": "bar"}}, Spec: corev1.PodSpec{NodeName: "node-1"}},
+	}
+	list := func(sel labels.Selector) ([]*corev1.Pod, error) {
+		var out []*corev1.Pod
+		for _, pod := range cached {
+			if sel.Matches(labels.Set(pod.Labels)) {
+				out = append(out, pod)
+			}
+		}
+		return out, nil
+	}
+
+	pods, err := listCachedPods(list, metav1.ListOptions{LabelSelector: "app=foo", FieldSelector: "spec.nodeName=node-1"})
+	require.NoError(t, err, "Listing cached pods should not return error")
+	if assert.Len(t, pods, 1) {
+		assert.Equal(t, "a", pods[0].Name)
+	}
+
+	_, err = listCachedPods(list, metav1.ListOptions{FieldSelector: "spec.foo=bar"})
+	assert.Error(t, err, "Unsupported field selectors should return error")
+}
+
+func TestCheckNamespace(t *testing.T) {
+	t.Parallel()
+
+	scoped := newChecker(nil, nil, WithNamespace("foo"))
+	assert.NoError(t, scoped.checkNamespace("foo"), "Checking pods in scoped namespace should be allowed")
+	assert.ErrorIs(t, scoped.checkNamespace("bar"), ErrOtherNamespace, "Checking pods in another namespace should return error")
+	assert.ErrorIs(t, scoped.checkNamespace(""), ErrOtherNamespace, "Checking pods in all namespaces should return error")
+
+	assert.NoError(t, newChecker(nil, nil).checkNamespace(""), "Unscoped checker should check all namespaces")
+}
+
+// Clientset that lists pods with a function, so that list options can be inspected.
+// The fake clientset doesn't record limits or continue tokens.
+type listPodsClientset struct {
+	*fake.Clientset
+	list func(opts metav1.ListOptions) (*corev1.PodList, error)
+}
+
+type listPodsCoreV1 struct {
+	typedcorev1.CoreV1Interface
+	list func(opts metav1.ListOptions) (*corev1.PodList, error)
+}
+
+type listPods struct {
+	typedcorev1.PodInterface
+	list func(opts metav1.ListOptions) (*corev1.PodList, error)
+}
+
+func (c *listPodsClientset) CoreV1() typedcorev1.CoreV1Interface {
+	return &listPodsCoreV1{CoreV1Interface: c.Clientset.CoreV1(), list: c.list}
+}
+
+func (c *listPodsCoreV1) Pods(namespace string) typedcorev1.PodInterface {
+	return &listPods{PodInterface: c.CoreV1Interface.Pods(namespace), list: c.list}
+}
+
+func (p *listPods) List(_ context.Context, opts metav1.ListOptions) (*corev1.PodList, error) {
+	return p.list(opts)
+}
+
+// Serve pods a page at a time, with the index of the next pod as the continue token
+func pagedList(pods []corev1.Pod, opts metav1.ListOptions) *corev1.PodList {
+	start, _ := strconv.Atoi(opts.Continue)
+	end := start + int(opts.Limit)
+	if opts.Limit == 0 || end > len(pods) {
+		end = len(pods)
+	}
+
+	out := &corev1.PodList{Items: pods[start:end]}
+	if end < len(pods) {
+		out.ListMeta.Continue = strconv.Itoa(end)
+	}
+	return out
+}
+
+func TestCheckListedPodsPaging(t *testing.T) {
+	t.Parallel()
+
+	var pods []corev1.Pod
+	for _, name := range []string{"a", "b", "c", "d", "e"} {
+		pods = append(pods, *newRunningPod(name, "node-1", nil))
+	}
+
+	tests := []struct {
+		name     string
+		list     func(calls int, opts metav1.ListOptions) (*corev1.PodList, error)
+		expected []string
+		calls    int
+		err      bool // results are incomplete
+		failed   bool // no results are returned
+	}{
+		{
+			name: "all pages",
+			list: func(_ int, opts metav1.ListOptions) (*corev1.PodList, error) {
+				return pagedList(pods, opts), nil
+			},
+			expected: []string{"a", "b", "c", "d", "e"},
+			calls:    3,
+		},
+		{
+			name: "expired continue token with inconsistent continue token",
+			list: func(calls int, opts metav1.ListOptions) (*corev1.PodList, error) {
+				if calls == 2 {
+					err := kerrors.NewResourceExpired("continue token expired")
+					err.ErrStatus.ListMeta.Continue = opts.Continue
+					return nil, err
+				}
+				return pagedList(pods, opts), nil
+			},
+			expected: []string{"a", "b", "c", "d", "e"},
+			calls:    4,
+		},
+		{
+			name: "expired continue token restarts list",
+			list: func(calls int, opts metav1.ListOptions) (*corev1.PodList, error) {
+				if calls == 2 {
+					return nil, kerrors.NewResourceExpired("continue token expired")
+				}
+				return pagedList(pods, opts), nil
+			},
+			expected: []string{"a", "b", "c", "d", "e"},
+			calls:    5,
+		},
+		{
+			name: "list error returns listed pods",
+			list: func(calls int, opts metav1.ListOptions) (*corev1.PodList, error) {
+				if calls == 2 {
+					return nil, kerrors.NewForbidden(corev1.Resource("pods"), "", errors.New("forbidden"))
+				}
+				return pagedList(pods, opts), nil
+			},
+			expected: []string{"a", "b"},
+			calls:    2,
+			err:      true,
+		},
+		{
+			name: "list error on first page returns error",
+			list: func(calls int, opts metav1.ListOptions) (*corev1.PodList, error) {
+				return nil, kerrors.NewForbidden(corev1.Resource("pods"), "", errors.New("forbidden"))
+			},
+			calls:  1,
+			failed: true,
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			var (
+				mu    sync.Mutex
+				calls int
+			)
+			cs := &listPodsClientset{
+				Clientset: fake.NewSimpleClientset(),
+				list: func(opts metav1.ListOptions) (*corev1.PodList, error) {
+					mu.Lock()
+					defer mu.Unlock()
+					calls++
+					assert.Equal(t, int64(2), opts.Limit, "Pods should be listed in pages")
+					return tc.list(calls, opts)
+				},
+			}
+			c, err := NewCheckerForEvictor(
+				context.Background(),
+				cs,
+				nil,
+				WithOfflineEvaluation(),
+				WithDrainOptions(DrainOptions{Force: true}),
+				WithPolicyVersion(policy.V1),
+				WithPageSize(2),
+			)
+			require.NoError(t, err, "Creating checker should not return error")
+			defer c.Stop()
+
+			res, err := c.AllPods(context.Background(), "", time.Second, 2, WithAllResults())
+			ie := (*IncompleteError)(nil)
+			if tc.err {
+				assert.ErrorAs(t, err, &ie, "List errors should make results incomplete")
+			} else if tc.failed {
+				assert.True(t, kerrors.IsForbidden(err), "List error should be returned")
+				assert.False(t, errors.As(err, &ie), "Failing to list any pods should not return incomplete results")
+			} else {
+				assert.NoError(t, err, "Listing pods should not return error")
+			}
+			assert.ElementsMatch(t, tc.expected, podNames(res), "Each listed pod should be checked once")
+			assert.Equal(t, tc.calls, calls, "Pods should be listed with expected number of calls")
+		})
+	}
+}
+
+func TestCheckNoWorkers(t *testing.T) {
+	t.Parallel()
+
+	c := newFakeChecker(t, newFakeClientset(newRunningPod("a", "node-1", nil)))
+
+	_, err := c.AllPods(context.Background(), "", time.Second, 0)
+	assert.ErrorIs(t, err, ErrNoWorkers, "Checking pods without workers should return error")
+	_, err = c.PodsByName(context.Background(), time.Second, "default", 0, []string{"a"})
+	assert.ErrorIs(t, err, ErrNoWorkers, "Checking pods by name without workers should return error")
+}
+
+// Evictor that blocks dry-runs until a channel is closed
+type blockingEvictor struct {
+	wait <-chan struct{}
+}
+
+func (e blockingEvictor) DryRun(ctx context.Context, _ corev1.Pod) error {
+	select {
+	case <-e.wait:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func TestCheckListedPodsListsWhileChecking(t *testing.T) {
+	t.Parallel()
+
+	var pods []corev1.Pod
+	for _, name := range []string{"a", "b", "c", "d", "e"} {
+		pods = append(pods, *newRunningPod(name, "node-1", nil))
+	}
+
+	// block checks until the last page has been listed
+	listed := make(chan struct{})
+	cs := &listPodsClientset{
+		Clientset: fake.NewSimpleClientset(),
+		list: func(opts metav1.ListOptions) (*corev1.PodList, error) {
+			list := pagedList(pods, opts)
+			if list.Continue == "" {
+				close(listed)
+			}
+			return list, nil
+		},
+	}
+	c, err := NewCheckerForEvictor(
+		context.Background(),
+		cs,
+		blockingEvictor{wait: listed},
+		WithDrainOptions(DrainOptions{Force: true}),
+		WithPolicyVersion(policy.V1),
+		WithPageSize(2),
+	)
+	require.NoError(t, err, "Creating checker should not return error")
+	defer c.Stop()
+
+	res, err := c.AllPods(context.Background(), "", time.Second*5, 1, WithAllResults())
+	require.NoError(t, err, "Listing should not wait for pods to be checked")
+	assert.Len(t, res, len(pods), "All pods should be checked")
+}
+
+func TestCheckListedPodsBoundedQueue(t *testing.T) {
+	t.Parallel()
+
+	var pods []corev1.Pod
+	for i := 0; i < 100; i++ {
+		pods = append(pods, *newRunningPod("pod-"+strconv.Itoa(i), "node-1", nil))
+	}
+
+	var (
+		mu    sync.Mutex
+		calls int
+	)
+	cs := &listPodsClientset{
+		Clientset: fake.NewSimpleClientset(),
+		list: func(opts metav1.ListOptions) (*corev1.PodList, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			calls++
+			return pagedList(pods, opts), nil
+		},
+	}
+
+	// block checks until listing has stopped
+	release := make(chan struct{})
+	c, err := NewCheckerForEvictor(
+		context.Background(),
+		cs,
+		blockingEvictor{wait: release},
+		WithDrainOptions(DrainOptions{Force: true}),
+		WithPolicyVersion(policy.V1),
+		WithPageSize(2),
+	)
+	require.NoError(t, err, "Creating checker should not return error")
+	defer c.Stop()
+
+	done := make(chan struct{})
+	var res Results
+	go func() {
+		defer close(done)
+		res, err = c.AllPods(context.Background(), "", time.Second*5, 1, WithAllResults())
+	}()
+
+	time.Sleep(time.Millisecond * 200)
+	mu.Lock()
+	listed := calls
+	mu.Unlock()
+	// one page being checked, the queued pages & one page waiting to be queued
+	assert.LessOrEqual(t, listed, queuedPages+2, "Listing should stop while queued pods wait to be checked")
+
+	close(release)
+	<-done
+	require.NoError(t, err, "Checking pods should not return error")
+	assert.Len(t, res, len(pods), "All pods should be checked")
+}
@@ -0,0 +1,95 @@
+package checker
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/fhke/kubectl-draincheck/pkg/evictor"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// remaining disruption budget for a pod disruption budget during a simulation
+type budget struct {
+	disruptionsAllowed int32
+	currentHealthy     int32
+}
+
+// Locate pod disruption budgets for evictable pods, then simulate evicting them together
+func (c *Checker) simulateBudgets(ctx context.Context, timeout time.Duration, results Results) error {
+	for i := range results {
+		if results[i].Reason != nil || results[i].Disposition != DispositionEvict || results[i].PodDisruptionBudgets != nil {
+			continue
+		}
+
+		pdbs, err := c.pdbsForPod(ctx, timeout, &results[i].Pod)
+		if err != nil {
+			return fmt.Errorf("error simulating eviction of pod %s/%s: %w", results[i].Pod.Namespace, results[i].Pod.Name, err)
+		}
+		results[i].PodDisruptionBudgets = pdbs
+	}
+
+	simulateEvictions(results)
+
+	return nil
+}
+
+// Evict evictable pods in order of namespace & name, subtracting each eviction from
+// the budget of the pod's disruption budget. Pods that would be blocked by an
+// exhausted budget have their reason set to ErrBudgetExhausted.
+func simulateEvictions(results Results) {
+	// sort results so evictions happen in a stable order
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Pod.Namespace != results[j].Pod.Namespace {
+			return results[i].Pod.Namespace < results[j].Pod.Namespace
+		}
+		return results[i].Pod.Name < results[j].Pod.Name
+	})
+
+	budgets := make(map[types.NamespacedName]*budget)
+
+	for i := range results {
+		res := &results[i]
+
+		// pods that are already unevictable or aren't evicted don't use any
+		// budget, and pods with multiple PDBs are rejected by the eviction API
+		if res.Reason != nil || res.Disposition != DispositionEvict || len(res.PodDisruptionBudgets) != 1 {
+			continue
+		}
+
+		// the eviction API ignores pod disruption budgets for pods that are not running
+		if evictor.CanIgnorePDB(&res.Pod) {
+			continue
+		}
+
+		pdb := res.PodDisruptionBudgets[0]
+		key := types.NamespacedName{Namespace: pdb.Namespace, Name: pdb.Name}
+		b, ok := budgets[key]
+		if !ok {
+			b = &budget{
+				disruptionsAllowed: pdb.Status.DisruptionsAllowed,
+				currentHealthy:     pdb.Status.CurrentHealthy,
+			}
+			budgets[key] = b
+		}
+
+		// The eviction API may allow pods that are not ready to be evicted
+		// without using any budget
+		if evictor.EvictsWithoutBudget(&res.Pod, pdb, b.currentHealthy) {
+			continue
+		}
+
+		if b.disruptionsAllowed <= 0 {
+			res.Reason = ErrBudgetExhausted
+			res.Status = StatusUnevictable
+			res.BlockingPDB = pdb.Name
+			continue
+		}
+
+		b.disruptionsAllowed--
+		if evictor.IsPodReady(&res.Pod) {
+			b.currentHealthy--
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package checker
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestSimulateEvictions(t *testing.T) {
+	t.Parallel()
+
+	pdb := &policyv1.PodDisruptionBudget{
+		ObjectMeta: metav1.ObjectMeta{Name: "pdb", Namespace: "default"},
+		Status: policyv1.PodDisruptionBudgetStatus{
+			DisruptionsAllowed: 1,
+			CurrentHealthy:     3,
+			DesiredHealthy:     2,
+		},
+	}
+
+	results := Results{
+		{Disposition: DispositionEvict, Pod: newTestPod("c", true), PodDisruptionBudgets: []*policyv1.PodDisruptionBudget{pdb}},
+		{Disposition: DispositionEvict, Pod: newTestPod("b", true), PodDisruptionBudgets: []*policyv1.PodDisruptionBudget{pdb}},
+		{Disposition: DispositionEvict, Pod: newTestPod("a", true), PodDisruptionBudgets: []*policyv1.PodDisruptionBudget{pdb}},
+		{Disposition: DispositionEvict, Pod: newTestPod("d", true)},
+	}
+
+	simulateEvictions(results)
+
+	// first pod by name uses the budget, later pods sharing the PDB are blocked
+	assert.Equal(t, "a", results[0].Pod.Name)
+	assert.NoError(t, results[0].Reason)
+	assert.Equal(t, ErrBudgetExhausted, results[1].Reason)
+	assert.Equal(t, ErrBudgetExhausted, results[2].Reason)
+	// pods without PDBs are never blocked
+	assert.NoError(t, results[3].Reason)
+}
+
+func TestSimulateEvictionsUnreadyPod(t *testing.T) {
+	t.Parallel()
+
+	pdb := &policyv1.PodDisruptionBudget{
+		ObjectMeta: metav1.ObjectMeta{Name: "pdb", Namespace: "default"},
+		Status: policyv1.PodDisruptionBudgetStatus{
+			DisruptionsAllowed: 1,
+			CurrentHealthy:     2,
+			DesiredHealthy:     1,
+		},
+	}
+
+	results := Results{
+		{Disposition: DispositionEvict, Pod: newTestPod("a", false), PodDisruptionBudgets: []*policyv1.PodDisruptionBudget{pdb}},
+		{Disposition: DispositionEvict, Pod: newTestPod("b", true), PodDisruptionBudgets: []*policyv1.PodDisruptionBudget{pdb}},
+	}
+
+	simulateEvictions(results)
+
+	// unready pods don't use any budget
+	assert.NoError(t, results[0].Reason)
+	assert.NoError(t, results[1].Reason)
+}
+
+func TestSimulateEvictionsAlwaysAllow(t *testing.T) {
+	t.Parallel()
+
+	alwaysAllow := policyv1.AlwaysAllow
+	pdb := &policyv1.PodDisruptionBudget{
+		ObjectMeta: metav1.ObjectMeta{Name: "pdb", Namespace: "default"},
+		Spec:       policyv1.PodDisruptionBudgetSpec{UnhealthyPodEvictionPolicy: &alwaysAllow},
+		Status: policyv1.PodDisruptionBudgetStatus{
+			DisruptionsAllowed: 0,
+			CurrentHealthy:     0,
+			DesiredHealthy:     1,
+		},
+	}
+
+	results := Results{
+		{Disposition: DispositionEvict, Pod: newTestPod("a", false), PodDisruptionBudgets: []*policyv1.PodDisruptionBudget{pdb}},
+		{Disposition: DispositionEvict, Pod: newTestPod("b", true), PodDisruptionBudgets: []*policyv1.PodDisruptionBudget{pdb}},
+	}
+
+	simulateEvictions(results)
+
+	// unready pods are always evicted, but ready pods still need a disruption
+	assert.NoError(t, results[0].Reason)
+	assert.Equal(t, ErrBudgetExhausted, results[1].Reason)
+	assert.Equal(t, "pdb", results[1].BlockingPDB, "Exhausted PDB should be named")
+}
+
+func TestSimulateEvictionsIgnoredPDB(t *testing.T) {
+	t.Parallel()
+
+	exhausted := &policyv1.PodDisruptionBudget{
+		ObjectMeta: metav1.ObjectMeta{Name: "exhausted", Namespace: "default"},
+		Status: policyv1.PodDisruptionBudgetStatus{
+			DisruptionsAllowed: 0,
+			CurrentHealthy:     0,
+			DesiredHealthy:     1,
+		},
+	}
+	pdb := &policyv1.PodDisruptionBudget{
+		ObjectMeta: metav1.ObjectMeta{Name: "pdb", Namespace: "default"},
+		Status: policyv1.PodDisruptionBudgetStatus{
+			DisruptionsAllowed: 1,
+			CurrentHealthy:     1,
+			DesiredHealthy:     0,
+		},
+	}
+
+	pending := newTestPod("a", false)
+	pending.Status.Phase = corev1.PodPending
+	succeeded := newTestPod("b", false)
+	succeeded.Status.Phase = corev1.PodSucceeded
+	running := newTestPod("c", true)
+	running.Status.Phase = corev1.PodRunning
+
+	results := Results{
+		{Disposition: DispositionEvict, Pod: pending, PodDisruptionBudgets: []*policyv1.PodDisruptionBudget{exhausted}},
+		{Disposition: DispositionEvict, Pod: succeeded, PodDisruptionBudgets: []*policyv1.PodDisruptionBudget{pdb}},
+		{Disposition: DispositionEvict, Pod: running, PodDisruptionBudgets: []*policyv1.PodDisruptionBudget{pdb}},
+	}
+
+	simulateEvictions(results)
+
+	// pod disruption budgets don't apply to pending or terminated pods, so they neither
+	// block them nor use up budget needed by running pods
+	assert.NoError(t, results[0].Reason, "Pending pod should not be blocked by an exhausted PDB")
+	assert.NoError(t, results[1].Reason, "Succeeded pod should not be blocked")
+	assert.NoError(t, results[2].Reason, "Succeeded pod should not use up budget needed by running pod")
+}
+
+func newTestPod(name string, ready bool) corev1.Pod {
+	status := corev1.ConditionFalse
+	if ready {
+		status = corev1.ConditionTrue
+	}
+
+	return corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+		Status: corev1.PodStatus{
+			Conditions: []corev1.PodCondition{
+				{Type: corev1.PodReady, Status: status},
+			},
+		},
+	}
+}
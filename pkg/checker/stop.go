@@ -1,5 +1,11 @@
 package checker
 
+// Stop the checker's informers. Stop can be called more than once.
 func (c *Checker) Stop() {
 	c.pdbLocator.Stop()
 }
+
+// Check whether the checker's informer caches have synced & are still running
+func (c *Checker) HasSynced() bool {
+	return c.pdbLocator.HasSynced()
+}
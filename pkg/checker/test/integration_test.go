@@ -14,7 +14,11 @@ func TestIntegration(t *testing.T) {
 	t.Parallel()
 
 	// create checker
-	ch, err := checker.NewChecker(context.TODO(), clientset)
+	ch, err := checker.NewChecker(
+		context.TODO(),
+		clientset,
+		checker.WithDrainOptions(checker.DrainOptions{IgnoreDaemonSets: true}),
+	)
 	require.NoError(t, err, "Creating checker should not return error")
 	require.NotNil(t, ch, "Checker should not be nil")
 	defer ch.Stop()
@@ -3,8 +3,10 @@ package checker
 import (
 	"github.com/fhke/kubectl-draincheck/pkg/evictor"
 	"github.com/fhke/kubectl-draincheck/pkg/locator"
+	"github.com/fhke/kubectl-draincheck/pkg/retry"
 	corev1 "k8s.io/api/core/v1"
 	policyv1 "k8s.io/api/policy/v1"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes"
 )
 
@@ -13,11 +15,108 @@ type (
 		k          kubernetes.Interface // kubernetes clientset interface
 		e          evictor.Evictor      // pod dry run evictor
 		pdbLocator *locator.PDBLocator
+		// options equivalent to kubectl drain flags
+		drainOptions DrainOptions
+		// evaluate cached PDBs instead of running eviction dry-runs
+		offline bool
+		// backoff for retrying API calls
+		backoff retry.Backoff
+		// number of pods to request from the API server per page
+		pageSize int64
+		// list pods from an informer cache instead of the API server
+		cachePods bool
+		// only check pods in namespace, if set
+		namespace string
+		// policy API version for evictions & pod disruption budgets, discovered if empty
+		policyVersion string
+		// name of the cluster, set on results
+		cluster string
+		// called when an informer's watch fails
+		onWatchError func(resource string, err error)
 	}
+	// Functional option for configuring a Checker
+	Option func(*Checker)
+
+	// Options equivalent to the kubectl drain flags that affect which pods are removed
+	DrainOptions struct {
+		IgnoreDaemonSets   bool // --ignore-daemonsets
+		DeleteEmptyDirData bool // --delete-emptydir-data
+		Force              bool // --force
+		DisableEviction    bool // --disable-eviction
+	}
+
+	// What kubectl drain would do with a pod
+	Disposition string
+
+	// Outcome of checking a pod
+	Status string
+
+	// Error returned when some pods could not be checked. Results are still
+	// returned, with StatusError for pods that could not be checked. If pods could
+	// not be listed, results are returned for the pods that were listed.
+	IncompleteError struct {
+		Errors []error
+	}
+
 	Result struct {
-		Reason               error                           `json:"reason"`
+		Cluster string `json:"cluster,omitempty"` // kubeconfig context, when checking multiple clusters
+		Reason  error  `json:"reason"`
+		Status  Status `json:"status"`
+		Message string `json:"message,omitempty"` // message returned by the API server
+		// name of the pod disruption budget that blocked eviction, if known
+		BlockingPDB          string                          `json:"blockingPodDisruptionBudget,omitempty"`
+		Disposition          Disposition                     `json:"disposition"`
 		Pod                  corev1.Pod                      `json:"pod"`
 		PodDisruptionBudgets []*policyv1.PodDisruptionBudget `json:"podDisruptionBudgets"`
 	}
 	Results []Result
+
+	// An ordered list of batches of nodes to drain. Nodes within a batch can be
+	// drained in parallel without any pod disruption budget blocking evictions.
+	Plan struct {
+		Batches []Batch       `json:"batches"`
+		Blocked []BlockedNode `json:"blocked"`
+	}
+	Batch struct {
+		Nodes []string `json:"nodes"`
+	}
+	// A node that cannot be drained without a pod disruption budget blocking evictions
+	BlockedNode struct {
+		Node                 string                          `json:"node"`
+		Reason               error                           `json:"reason"`
+		PodDisruptionBudgets []*policyv1.PodDisruptionBudget `json:"podDisruptionBudgets"`
+	}
+
+	// disruptions that draining a node uses for each pod disruption budget
+	nodeUsage struct {
+		node         string
+		disruptions  map[types.NamespacedName]int32
+		multiplePDBs []*policyv1.PodDisruptionBudget // PDBs on pods with more than one PDB
+		refused      error                           // why kubectl drain would refuse to drain the node
+	}
+
+	// Functional option for selecting pods to check
+	CheckOption func(*checkConfig)
+	checkConfig struct {
+		labelSelector string // label selector for pods
+		fieldSelector string // field selector for pods
+		// glob patterns for namespaces to include & exclude
+		namespaces, excludedNamespaces []string
+		// label selector for namespaces
+		namespaceSelector string
+		// simulate evicting all selected pods together
+		simulateBudgets bool
+		// return results for all pods, not only pods that cannot be evicted
+		allResults bool
+		// handler for streaming results
+		onResult func(Result)
+	}
+
+	// Functional option for configuring table output
+	TableOption func(*tableConfig)
+	tableConfig struct {
+		nodeColumn    bool // include node column
+		clusterColumn bool // include cluster column
+		statusColumn  bool // include status column
+	}
 )
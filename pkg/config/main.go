@@ -0,0 +1,150 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/pflag"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"sigs.k8s.io/yaml"
+)
+
+// Prefix of environment variables that set flags, e.g. DRAINCHECK_API_TIMEOUT
+const EnvPrefix = "DRAINCHECK_"
+
+var (
+	ErrUnknownProfile = errors.New("unknown profile")
+	ErrUnknownFlag    = errors.New("unknown flag")
+)
+
+// Get the default config file path, $XDG_CONFIG_HOME/draincheck/config.yaml or
+// ~/.config/draincheck/config.yaml
+func DefaultPath() (string, error) {
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		dir = filepath.Join(home, ".config")
+	}
+
+	return filepath.Join(dir, "draincheck", "config.yaml"), nil
+}
+
+// Load a config file. If optional is true, a missing file is treated as empty.
+func Load(path string, optional bool) (*File, error) {
+	data, err := os.ReadFile(path)
+	if optional && errors.Is(err, os.ErrNotExist) {
+		return &File{}, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("error reading config file: %w", err)
+	}
+
+	f := &File{}
+	// decode numbers as json.Number, so that they are passed to flags as written
+	if err := yaml.UnmarshalStrict(data, f, func(d *json.Decoder) *json.Decoder {
+		d.UseNumber()
+		return d
+	}); err != nil {
+		return nil, fmt.Errorf("error parsing config file %s: %w", path, err)
+	}
+
+	return f, nil
+}
+
+// Get values for a profile, merged over the defaults. An empty name returns the defaults.
+func (f *File) Profile(name string) (Values, error) {
+	out := make(Values, len(f.Defaults))
+	for k, v := range f.Defaults {
+		out[k] = v
+	}
+	if name == "" {
+		return out, nil
+	}
+
+	profile, ok := f.Profiles[name]
+	if !ok {
+		return nil, fmt.Errorf("%w %s", ErrUnknownProfile, name)
+	}
+	for k, v := range profile {
+		out[k] = v
+	}
+
+	return out, nil
+}
+
+// Get the names of all flags set by the config file
+func (f *File) Keys() []string {
+	keys := make(map[string]bool)
+	for k := range f.Defaults {
+		keys[k] = true
+	}
+	for _, profile := range f.Profiles {
+		for k := range profile {
+			keys[k] = true
+		}
+	}
+
+	out := make([]string, 0, len(keys))
+	for k := range keys {
+		out = append(out, k)
+	}
+	sort.Strings(out)
+
+	return out
+}
+
+// Get the name of the environment variable for a flag, e.g. DRAINCHECK_API_TIMEOUT
+func EnvName(flag string) string {
+	return EnvPrefix + strings.ToUpper(strings.ReplaceAll(flag, "-", "_"))
+}
+
+// Set flags that were not set on the command line. Flags are set from environment
+// variables, falling back to values, so that the order of precedence is flags, then
+// environment, then values.
+func Apply(flags *pflag.FlagSet, values Values, lookupEnv func(string) (string, bool)) error {
+	var errs []error
+
+	flags.VisitAll(func(flag *pflag.Flag) {
+		if flag.Changed || flag.Name == "help" {
+			return
+		}
+
+		if env, ok := lookupEnv(EnvName(flag.Name)); ok {
+			if err := flags.Set(flag.Name, env); err != nil {
+				errs = append(errs, fmt.Errorf("invalid value for %s: %w", EnvName(flag.Name), err))
+			}
+			return
+		}
+
+		if value, ok := values[flag.Name]; ok {
+			if err := setValue(flags, flag.Name, value); err != nil {
+				errs = append(errs, fmt.Errorf("invalid value for %s in config file: %w", flag.Name, err))
+			}
+		}
+	})
+
+	return utilerrors.NewAggregate(errs)
+}
+
+// Set a flag from a config file value, setting lists one item at a time
+func setValue(flags *pflag.FlagSet, name string, value interface{}) error {
+	items, ok := value.([]interface{})
+	if !ok {
+		items = []interface{}{value}
+	}
+
+	for _, item := range items {
+		if err := flags.Set(name, fmt.Sprint(item)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,81 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testConfig = `
+defaults:
+  workers: 20
+  api-timeout: 1m
+  output: yaml
+profiles:
+  nightly-audit:
+    output: json
+    all-namespaces: true
+    node: [node-1, node-2]
+`
+
+func TestLoad(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(testConfig), 0o600))
+
+	f, err := Load(path, false)
+	require.NoError(t, err, "Loading config file should not return error")
+	assert.Equal(t, []string{"all-namespaces", "api-timeout", "node", "output", "workers"}, f.Keys())
+
+	values, err := f.Profile("nightly-audit")
+	require.NoError(t, err, "Getting profile should not return error")
+	assert.Equal(t, "json", values["output"], "Profile should override defaults")
+	assert.Equal(t, "1m", values["api-timeout"], "Defaults should be used when not set by profile")
+
+	_, err = f.Profile("foo")
+	assert.ErrorIs(t, err, ErrUnknownProfile, "Unknown profiles should return error")
+
+	_, err = Load(filepath.Join(t.TempDir(), "missing.yaml"), true)
+	assert.NoError(t, err, "Missing optional config file should not return error")
+	_, err = Load(filepath.Join(t.TempDir(), "missing.yaml"), false)
+	assert.Error(t, err, "Missing config file should return error")
+}
+
+func TestApply(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(testConfig), 0o600))
+	f, err := Load(path, false)
+	require.NoError(t, err)
+	values, err := f.Profile("nightly-audit")
+	require.NoError(t, err)
+
+	flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	var (
+		workers = flags.Uint("workers", 10, "")
+		timeout = flags.Duration("api-timeout", time.Second*30, "")
+		output  = flags.String("output", "text", "")
+		all     = flags.Bool("all-namespaces", false, "")
+		nodes   = flags.StringArray("node", nil, "")
+	)
+	require.NoError(t, flags.Parse([]string{"--output", "ndjson"}))
+
+	env := map[string]string{"DRAINCHECK_WORKERS": "5"}
+	require.NoError(t, Apply(flags, values, func(k string) (string, bool) {
+		v, ok := env[k]
+		return v, ok
+	}))
+
+	assert.Equal(t, "ndjson", *output, "Flags should take precedence over config")
+	assert.Equal(t, uint(5), *workers, "Environment should take precedence over config")
+	assert.Equal(t, time.Minute, *timeout, "Defaults should be applied")
+	assert.True(t, *all, "Profile should be applied")
+	assert.Equal(t, []string{"node-1", "node-2"}, *nodes, "Lists should be applied")
+}
@@ -0,0 +1,13 @@
+package config
+
+type (
+	// Config file, holding default flag values & named profiles
+	File struct {
+		Defaults Values            `json:"defaults"`
+		Profiles map[string]Values `json:"profiles"`
+	}
+
+	// Flag values, keyed by flag name without leading dashes, e.g. api-timeout.
+	// Values are scalars, or lists for flags that can be specified multiple times.
+	Values map[string]interface{}
+)
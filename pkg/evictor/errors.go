@@ -2,47 +2,128 @@ package evictor
 
 import (
 	"errors"
+	"fmt"
+	"strings"
 
+	policyv1 "k8s.io/api/policy/v1"
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 var (
-	ErrTooManyPDBs   error = errors.New("multiple pod disruption budgets are acting on the same pod")
-	ErrNoDisruptions error = errors.New("pod disruption budget allows no disruptions")
-	ErrNotFound      error = errors.New("pod not found")
+	ErrTooManyPDBs     error = errors.New("multiple pod disruption budgets are acting on the same pod")
+	ErrNoDisruptions   error = errors.New("pod disruption budget allows no disruptions")
+	ErrNotFound        error = errors.New("pod not found")
+	ErrTooManyRequests error = errors.New("API server rejected request with too many requests")
+	ErrServerError     error = errors.New("API server returned an internal error")
 )
 
+// An error returned by the eviction API, classified using the status returned by the API server
+type EvictionError struct {
+	Reason  error  // one of the errors in this package
+	PDBName string // name of the pod disruption budget that blocked the eviction, if known
+	Message string // message returned by the API server
+}
+
+var _ error = &EvictionError{}
+
+// Get the reason for the error. Messages are included for errors that don't make a pod unevictable.
+func (e *EvictionError) Error() string {
+	if IsUnevictableError(e.Reason) || e.Message == "" {
+		return e.Reason.Error()
+	}
+
+	return fmt.Sprintf("%s: %s", e.Reason, e.Message)
+}
+
+func (e *EvictionError) Unwrap() error {
+	return e.Reason
+}
+
 func IsUnevictableError(err error) bool {
 	if err == nil {
 		return false
 	}
 
-	return err == ErrTooManyPDBs || err == ErrNoDisruptions
+	return errors.Is(err, ErrTooManyPDBs) || errors.Is(err, ErrNoDisruptions)
+}
+
+// Get the message returned by the API server for an eviction error, if there is one
+func MessageFor(err error) string {
+	if e := (*EvictionError)(nil); errors.As(err, &e) {
+		return e.Message
+	}
+
+	return ""
+}
+
+// Get the name of the pod disruption budget that blocked an eviction, if known
+func PDBNameFor(err error) string {
+	if e := (*EvictionError)(nil); errors.As(err, &e) {
+		return e.PDBName
+	}
+
+	return ""
 }
 
 // See https://kubernetes.io/docs/concepts/scheduling-eviction/api-eviction/#how-api-initiated-eviction-works
-// If a pod is unevictable, the API reason will either be "too many requests" or "internal server error"
+// If a pod is unevictable, the API reason will either be "too many requests" with a
+// DisruptionBudget cause or "internal server error". Both codes can also be returned for
+// other reasons, e.g. API priority & fairness throttling, so the status details are
+// used to classify errors.
 func errorFor(e error) error {
 	if e == nil {
 		return nil
 	}
 
-	switch codeForKError(e) {
+	status := kerrors.APIStatus(nil)
+	if !errors.As(e, &status) {
+		return e
+	}
+	s := status.Status()
+
+	switch s.Code {
 	case 404:
 		return ErrNotFound
-	case 500:
-		return ErrTooManyPDBs
 	case 429:
-		return ErrNoDisruptions
+		if pdbName, ok := disruptionBudgetCause(s); ok {
+			return &EvictionError{Reason: ErrNoDisruptions, PDBName: pdbName, Message: s.Message}
+		}
+		return &EvictionError{Reason: ErrTooManyRequests, Message: s.Message}
+	case 500:
+		if strings.Contains(s.Message, "more than one PodDisruptionBudget") {
+			return &EvictionError{Reason: ErrTooManyPDBs, Message: s.Message}
+		}
+		return &EvictionError{Reason: ErrServerError, Message: s.Message}
+	case 403:
+		// The API forbids evictions if a PDB is in an invalid state, e.g. negative disruptions allowed
+		if s.Details != nil && s.Details.Group == policyv1.GroupName && s.Details.Kind == "poddisruptionbudget" {
+			return &EvictionError{Reason: ErrNoDisruptions, PDBName: s.Details.Name, Message: s.Message}
+		}
+		return e
 	default:
 		return e
 	}
 }
 
-// get status code for an error from Kubernetes
-func codeForKError(err error) int32 {
-	if status := kerrors.APIStatus(nil); errors.As(err, &status) {
-		return status.Status().Code
+// Get the name of the pod disruption budget from a DisruptionBudget cause
+func disruptionBudgetCause(s metav1.Status) (string, bool) {
+	if s.Details == nil {
+		return "", false
+	}
+
+	for _, cause := range s.Details.Causes {
+		if cause.Type != policyv1.DisruptionBudgetCause {
+			continue
+		}
+
+		// cause messages are in the form "The disruption budget <name> ..."
+		var pdbName string
+		if _, err := fmt.Sscanf(cause.Message, "The disruption budget %s ", &pdbName); err != nil {
+			return "", true
+		}
+		return pdbName, true
 	}
-	return 0
+
+	return "", false
 }
@@ -5,6 +5,10 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 func TestIsUnevictableError(t *testing.T) {
@@ -16,3 +20,36 @@ func TestIsUnevictableError(t *testing.T) {
 	assert.False(t, IsUnevictableError(ErrNotFound))
 	assert.False(t, IsUnevictableError(errors.New("foo")))
 }
+
+func TestErrorFor(t *testing.T) {
+	t.Parallel()
+
+	// PDB blocking eviction
+	budgetErr := kerrors.NewTooManyRequests("Cannot evict pod as it would violate the pod's disruption budget.", 0)
+	budgetErr.ErrStatus.Details.Causes = append(budgetErr.ErrStatus.Details.Causes, metav1.StatusCause{
+		Type:    policyv1.DisruptionBudgetCause,
+		Message: "The disruption budget foo needs 2 healthy pods and has 2 currently",
+	})
+	err := errorFor(budgetErr)
+	assert.ErrorIs(t, err, ErrNoDisruptions)
+	assert.Equal(t, "foo", err.(*EvictionError).PDBName)
+	assert.Equal(t, "Cannot evict pod as it would violate the pod's disruption budget.", MessageFor(err))
+
+	// Multiple PDBs
+	err = errorFor(kerrors.NewInternalError(errors.New("This pod has more than one PodDisruptionBudget, which the eviction subresource does not support.")))
+	assert.ErrorIs(t, err, ErrTooManyPDBs)
+
+	// Invalid PDB
+	err = errorFor(kerrors.NewForbidden(policyv1.Resource("poddisruptionbudget"), "bar", errors.New("pdb disruptions allowed is negative")))
+	assert.ErrorIs(t, err, ErrNoDisruptions)
+	assert.Equal(t, "bar", err.(*EvictionError).PDBName)
+
+	// Errors that are not caused by PDBs
+	err = errorFor(kerrors.NewTooManyRequests("too many requests, please try again later", 1))
+	assert.ErrorIs(t, err, ErrTooManyRequests)
+	assert.False(t, IsUnevictableError(err))
+	err = errorFor(kerrors.NewInternalError(errors.New("etcdserver: request timed out")))
+	assert.ErrorIs(t, err, ErrServerError)
+	assert.False(t, IsUnevictableError(err))
+	assert.Equal(t, ErrNotFound, errorFor(kerrors.NewNotFound(corev1.Resource("pods"), "baz")))
+}
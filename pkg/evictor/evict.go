@@ -2,31 +2,61 @@ package evictor
 
 import (
 	"context"
+	"fmt"
 
+	"github.com/fhke/kubectl-draincheck/pkg/policy"
+	"github.com/fhke/kubectl-draincheck/pkg/retry"
 	corev1 "k8s.io/api/core/v1"
 	policyv1 "k8s.io/api/policy/v1"
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 func (e *evictorImpl) DryRun(ctx context.Context, pod corev1.Pod) error {
+	err := retry.Do(ctx, e.backoff, isRetriable, func() error {
+		return e.dryRun(ctx, pod)
+	})
+
+	return errorFor(err)
+}
+
+func (e *evictorImpl) dryRun(ctx context.Context, pod corev1.Pod) error {
+	deleteOptions := &metav1.DeleteOptions{
+		// DryRun All tests the pod eligibility for eviction rather than actually evicting
+		DryRun: []string{
+			"All",
+		},
+		PropagationPolicy: deletionPropagationPtr(metav1.DeletePropagationForeground),
+	}
+
 	// create an eviction object
-	err := e.k.PolicyV1().Evictions(pod.Namespace).Evict(
-		ctx,
-		&policyv1.Eviction{
-			// ObjectMeta represents the pod that we want to check eviction for
-			ObjectMeta: pod.ObjectMeta,
-			DeleteOptions: &metav1.DeleteOptions{
-				// DryRun All tests the pod eligibility for eviction rather than actually evicting
-				DryRun: []string{
-					"All",
-				},
-				PropagationPolicy: deletionPropagationPtr(metav1.DeletePropagationForeground),
+	switch e.policyVersion {
+	case policy.V1:
+		return e.k.PolicyV1().Evictions(pod.Namespace).Evict(
+			ctx,
+			&policyv1.Eviction{
+				// ObjectMeta represents the pod that we want to check eviction for
+				ObjectMeta:    pod.ObjectMeta,
+				DeleteOptions: deleteOptions,
 			},
-		},
-	)
+		)
+	case policy.V1beta1:
+		return e.k.PolicyV1beta1().Evictions(pod.Namespace).Evict(
+			ctx,
+			&policyv1beta1.Eviction{
+				ObjectMeta:    pod.ObjectMeta,
+				DeleteOptions: deleteOptions,
+			},
+		)
+	default:
+		return fmt.Errorf("unsupported policy API version %s", e.policyVersion)
+	}
+}
 
-	return errorFor(err)
+// Retry transient errors, unless they were caused by the pod being unevictable
+func isRetriable(err error) bool {
+	return retry.IsTransient(err) && !IsUnevictableError(errorFor(err))
 }
 
 func deletionPropagationPtr(in metav1.DeletionPropagation) *metav1.DeletionPropagation {
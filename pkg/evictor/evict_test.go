@@ -0,0 +1,35 @@
+package evictor
+
+import (
+	"context"
+	"testing"
+
+	"github.com/fhke/kubectl-draincheck/pkg/policy"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestDryRunPolicyVersion(t *testing.T) {
+	t.Parallel()
+
+	pod := corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "default"}}
+
+	for version, expected := range map[string]interface{}{
+		policy.V1:      &policyv1.Eviction{},
+		policy.V1beta1: &policyv1beta1.Eviction{},
+	} {
+		cs := fake.NewSimpleClientset(&pod)
+		e := NewEvictor(cs, WithPolicyVersion(version))
+
+		assert.NoError(t, e.DryRun(context.Background(), pod), "Dry-run should not return error (%s)", version)
+		if actions := cs.Actions(); assert.Len(t, actions, 1, "Dry-run should create one eviction (%s)", version) {
+			assert.Equal(t, "eviction", actions[0].GetSubresource())
+			assert.IsType(t, expected, actions[0].(k8stesting.CreateAction).GetObject(), "Eviction should use policy API version (%s)", version)
+		}
+	}
+}
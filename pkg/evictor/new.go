@@ -1,11 +1,35 @@
 package evictor
 
 import (
+	"github.com/fhke/kubectl-draincheck/pkg/policy"
+	"github.com/fhke/kubectl-draincheck/pkg/retry"
 	"k8s.io/client-go/kubernetes"
 )
 
-func NewEvictor(k kubernetes.Interface) Evictor {
-	return &evictorImpl{
-		k: k,
+func NewEvictor(k kubernetes.Interface, opts ...Option) Evictor {
+	e := &evictorImpl{
+		k:             k,
+		backoff:       retry.NoRetries,
+		policyVersion: policy.V1,
+	}
+	for _, opt := range opts {
+		opt(e)
+	}
+
+	return e
+}
+
+// Retry eviction dry-runs that fail with transient errors, e.g. throttling by the API server
+func WithRetry(b retry.Backoff) Option {
+	return func(e *evictorImpl) {
+		e.backoff = b
+	}
+}
+
+// Create evictions with a policy API version, e.g. policy.V1beta1 for clusters
+// older than 1.21. Defaults to policy.V1.
+func WithPolicyVersion(version string) Option {
+	return func(e *evictorImpl) {
+		e.policyVersion = version
 	}
 }
@@ -0,0 +1,105 @@
+package evictor
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// Evaluate whether the eviction API would allow a pod to be evicted, using only the
+// pod disruption budgets acting on the pod. This mirrors the checks made by the
+// eviction API, so no permission to create evictions is needed.
+func Evaluate(pod *corev1.Pod, pdbs []*policyv1.PodDisruptionBudget) error {
+	// the eviction API ignores pod disruption budgets for pods that are not running
+	if CanIgnorePDB(pod) {
+		return nil
+	}
+
+	switch len(pdbs) {
+	case 0:
+		return nil
+	case 1:
+	default:
+		return ErrTooManyPDBs
+	}
+
+	pdb := pdbs[0]
+
+	// pods that are not ready may be evicted without using any budget
+	if EvictsWithoutBudget(pod, pdb, pdb.Status.CurrentHealthy) {
+		return nil
+	}
+
+	// the eviction API rejects evictions until the disruption controller has
+	// processed the latest PDB spec
+	if pdb.Status.ObservedGeneration < pdb.Generation || pdb.Status.DisruptionsAllowed <= 0 {
+		return &EvictionError{Reason: ErrNoDisruptions, PDBName: pdb.Name}
+	}
+
+	// check the budget against the spec, in case the status is out of date
+	if desired, ok := desiredHealthy(pdb); ok && pdb.Status.CurrentHealthy-1 < desired {
+		return &EvictionError{Reason: ErrNoDisruptions, PDBName: pdb.Name}
+	}
+
+	return nil
+}
+
+// Get the number of healthy pods required by a pod disruption budget's spec
+func desiredHealthy(pdb *policyv1.PodDisruptionBudget) (int32, bool) {
+	expected := int(pdb.Status.ExpectedPods)
+
+	switch {
+	case pdb.Spec.MinAvailable != nil:
+		minAvailable, err := intstr.GetScaledValueFromIntOrPercent(pdb.Spec.MinAvailable, expected, true)
+		if err != nil {
+			return 0, false
+		}
+		return int32(minAvailable), true
+	case pdb.Spec.MaxUnavailable != nil:
+		maxUnavailable, err := intstr.GetScaledValueFromIntOrPercent(pdb.Spec.MaxUnavailable, expected, true)
+		if err != nil {
+			return 0, false
+		}
+		return int32(expected - maxUnavailable), true
+	default:
+		return 0, false
+	}
+}
+
+// Check whether the eviction API would ignore pod disruption budgets for a pod, because
+// it is pending, has terminated or is being deleted
+func CanIgnorePDB(pod *corev1.Pod) bool {
+	switch pod.Status.Phase {
+	case corev1.PodSucceeded, corev1.PodFailed, corev1.PodPending:
+		return true
+	}
+
+	return pod.DeletionTimestamp != nil
+}
+
+// Check whether the eviction API would evict a pod without using any of a pod disruption
+// budget's disruptions, given the number of pods that are currently healthy. Pods that
+// are not ready are evicted if the budget's unhealthy pod eviction policy is AlwaysAllow,
+// or otherwise as long as the budget is healthy.
+func EvictsWithoutBudget(pod *corev1.Pod, pdb *policyv1.PodDisruptionBudget, currentHealthy int32) bool {
+	if IsPodReady(pod) {
+		return false
+	}
+
+	if policy := pdb.Spec.UnhealthyPodEvictionPolicy; policy != nil && *policy == policyv1.AlwaysAllow {
+		return true
+	}
+
+	return currentHealthy >= pdb.Status.DesiredHealthy && pdb.Status.DesiredHealthy > 0
+}
+
+// Check whether a pod has a Ready condition set to true
+func IsPodReady(pod *corev1.Pod) bool {
+	for _, cond := range pod.Status.Conditions {
+		if cond.Type == corev1.PodReady {
+			return cond.Status == corev1.ConditionTrue
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,80 @@
+package evictor
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func TestEvaluate(t *testing.T) {
+	t.Parallel()
+
+	var (
+		running = &corev1.Pod{
+			Status: corev1.PodStatus{
+				Phase:      corev1.PodRunning,
+				Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionTrue}},
+			},
+		}
+		pending    = &corev1.Pod{Status: corev1.PodStatus{Phase: corev1.PodPending}}
+		minOne     = intstr.FromInt(1)
+		minHalf    = intstr.FromString("50%")
+		allowOne   = newTestPDB(&minOne, 1, 2, 2)
+		allowNone  = newTestPDB(&minOne, 0, 1, 1)
+		staleAllow = newTestPDB(&minHalf, 1, 2, 4) // needs 2 of 4 pods, but only 2 are healthy
+	)
+
+	assert.NoError(t, Evaluate(running, nil), "Pods without PDBs should be evictable")
+	assert.NoError(t, Evaluate(running, []*policyv1.PodDisruptionBudget{allowOne}), "Pods with a PDB allowing disruptions should be evictable")
+	assert.ErrorIs(t, Evaluate(running, []*policyv1.PodDisruptionBudget{allowNone}), ErrNoDisruptions)
+	assert.ErrorIs(t, Evaluate(running, []*policyv1.PodDisruptionBudget{staleAllow}), ErrNoDisruptions)
+	allowNone.Name = "foo"
+	assert.Equal(t, "foo", PDBNameFor(Evaluate(running, []*policyv1.PodDisruptionBudget{allowNone})), "Blocking PDB should be named")
+	assert.Equal(t, ErrTooManyPDBs, Evaluate(running, []*policyv1.PodDisruptionBudget{allowOne, allowOne}))
+	assert.NoError(t, Evaluate(pending, []*policyv1.PodDisruptionBudget{allowNone}), "PDBs should be ignored for pending pods")
+}
+
+func TestEvaluateUnhealthyPodEvictionPolicy(t *testing.T) {
+	t.Parallel()
+
+	var (
+		unready = &corev1.Pod{
+			Status: corev1.PodStatus{
+				Phase:      corev1.PodRunning,
+				Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionFalse}},
+			},
+		}
+		minOne      = intstr.FromInt(1)
+		alwaysAllow = policyv1.AlwaysAllow
+		ifHealthy   = policyv1.IfHealthyBudget
+	)
+
+	// budget is unhealthy, so unready pods can only be evicted with AlwaysAllow
+	unhealthy := newTestPDB(&minOne, 0, 0, 1)
+	assert.ErrorIs(t, Evaluate(unready, []*policyv1.PodDisruptionBudget{unhealthy}), ErrNoDisruptions, "Unready pods should not be evicted when budget is unhealthy")
+	unhealthy.Spec.UnhealthyPodEvictionPolicy = &ifHealthy
+	assert.ErrorIs(t, Evaluate(unready, []*policyv1.PodDisruptionBudget{unhealthy}), ErrNoDisruptions, "IfHealthyBudget should not evict unready pods when budget is unhealthy")
+	unhealthy.Spec.UnhealthyPodEvictionPolicy = &alwaysAllow
+	assert.NoError(t, Evaluate(unready, []*policyv1.PodDisruptionBudget{unhealthy}), "AlwaysAllow should evict unready pods")
+
+	// budget is healthy, so unready pods can be evicted without using disruptions
+	healthy := newTestPDB(&minOne, 0, 1, 2)
+	assert.NoError(t, Evaluate(unready, []*policyv1.PodDisruptionBudget{healthy}), "Unready pods should be evicted when budget is healthy")
+}
+
+func newTestPDB(minAvailable *intstr.IntOrString, disruptionsAllowed, currentHealthy, expectedPods int32) *policyv1.PodDisruptionBudget {
+	return &policyv1.PodDisruptionBudget{
+		Spec: policyv1.PodDisruptionBudgetSpec{
+			MinAvailable: minAvailable,
+		},
+		Status: policyv1.PodDisruptionBudgetStatus{
+			DisruptionsAllowed: disruptionsAllowed,
+			CurrentHealthy:     currentHealthy,
+			DesiredHealthy:     1,
+			ExpectedPods:       expectedPods,
+		},
+	}
+}
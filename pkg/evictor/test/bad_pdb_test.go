@@ -53,5 +53,5 @@ func TestBlockingPDB(t *testing.T) {
 	// try to evict the pod
 	err = evictor.NewEvictor(clientset).DryRun(context.TODO(), *pod)
 	require.Error(t, err, "Eviction attempt should raise error")
-	assert.Equal(t, evictor.ErrNoDisruptions, err, "Eviction error should be unevictable")
+	assert.ErrorIs(t, err, evictor.ErrNoDisruptions, "Eviction error should be unevictable")
 }
@@ -61,5 +61,5 @@ func TestMultiplePDBs(t *testing.T) {
 
 	// validate that correct error is returned
 	require.Error(t, err, "Eviction attempt should raise error")
-	assert.Equal(t, evictor.ErrTooManyPDBs, err, "Eviction error should be unevictable")
+	assert.ErrorIs(t, err, evictor.ErrTooManyPDBs, "Eviction error should be unevictable")
 }
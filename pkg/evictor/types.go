@@ -3,6 +3,7 @@ package evictor
 import (
 	"context"
 
+	"github.com/fhke/kubectl-draincheck/pkg/retry"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/client-go/kubernetes"
 )
@@ -12,9 +13,15 @@ type (
 		DryRun(ctx context.Context, pod corev1.Pod) error // test a pod for
 	}
 
+	// Functional option for configuring an Evictor
+	Option func(*evictorImpl)
+
 	// implementation of the Evictor interface
 	evictorImpl struct {
-		k kubernetes.Interface
+		k       kubernetes.Interface
+		backoff retry.Backoff // backoff for retrying transient errors
+		// policy API version to create evictions with
+		policyVersion string
 	}
 )
 
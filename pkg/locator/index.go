@@ -0,0 +1,125 @@
+package locator
+
+import (
+	"sync"
+
+	"github.com/fhke/kubectl-draincheck/pkg/policy"
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/tools/cache"
+)
+
+// Index of pod disruption budgets by namespace, storing the converted selector for
+// each pod disruption budget so that selectors are only converted when a pod
+// disruption budget changes. The index is kept up to date by informer event handlers.
+type selectorIndex struct {
+	mu sync.RWMutex
+	// index entries, keyed by namespace & then name
+	entries map[string]map[string]indexEntry
+}
+
+type indexEntry struct {
+	pdb      *policyv1.PodDisruptionBudget
+	selector labels.Selector // nil if the selector is invalid
+}
+
+func newSelectorIndex() *selectorIndex {
+	return &selectorIndex{
+		entries: make(map[string]map[string]indexEntry),
+	}
+}
+
+// Get event handlers that keep the index up to date
+func (i *selectorIndex) handlers() cache.ResourceEventHandler {
+	return cache.ResourceEventHandlerFuncs{
+		AddFunc: i.add,
+		UpdateFunc: func(_, newObj interface{}) {
+			i.add(newObj)
+		},
+		DeleteFunc: i.delete,
+	}
+}
+
+// Add or update a pod disruption budget in the index
+func (i *selectorIndex) add(obj interface{}) {
+	pdb, ok := policy.ToV1(obj)
+	if !ok {
+		return
+	}
+
+	entry := indexEntry{
+		pdb: pdb,
+	}
+	if selector, err := metav1.LabelSelectorAsSelector(pdb.Spec.Selector); err == nil {
+		entry.selector = selector
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if i.entries[pdb.Namespace] == nil {
+		i.entries[pdb.Namespace] = make(map[string]indexEntry)
+	}
+	i.entries[pdb.Namespace][pdb.Name] = entry
+}
+
+// Remove a pod disruption budget from the index
+func (i *selectorIndex) delete(obj interface{}) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	pdb, ok := policy.ToV1(obj)
+	if !ok {
+		return
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	delete(i.entries[pdb.Namespace], pdb.Name)
+	if len(i.entries[pdb.Namespace]) == 0 {
+		delete(i.entries, pdb.Namespace)
+	}
+}
+
+// Get the pod disruption budgets matching a pod
+func (i *selectorIndex) pdbsForPod(pod *corev1.Pod) []*policyv1.PodDisruptionBudget {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	pdbs := []*policyv1.PodDisruptionBudget{}
+	for _, entry := range i.entries[pod.Namespace] {
+		if selectorMatches(entry.selector, pod) {
+			pdbs = append(pdbs, entry.pdb)
+		}
+	}
+
+	// sort by name, as map iteration order is random
+	sortPDBs(pdbs)
+
+	return pdbs
+}
+
+// Check whether the index contains a pod disruption budget
+func (i *selectorIndex) contains(pdb *policyv1.PodDisruptionBudget) bool {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	_, ok := i.entries[pdb.Namespace][pdb.Name]
+	return ok
+}
+
+// Get the selector for a pod disruption budget
+func (i *selectorIndex) selectorFor(namespace, name string) (labels.Selector, bool) {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	entry, ok := i.entries[namespace][name]
+	if !ok || entry.selector == nil {
+		return nil, false
+	}
+
+	return entry.selector, true
+}
@@ -0,0 +1,102 @@
+package locator
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/fhke/kubectl-draincheck/pkg/policy"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/cache"
+)
+
+func TestSelectorIndex(t *testing.T) {
+	t.Parallel()
+
+	var (
+		idx = newSelectorIndex()
+		foo = newTestPDB("foo", "default", map[string]string{"app": "foo"})
+		all = newTestPDB("all", "default", nil)
+		bar = newTestPDB("bar", "other", map[string]string{"app": "foo"})
+		pod = &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "foo-1",
+				Namespace: "default",
+				Labels:    map[string]string{"app": "foo"},
+			},
+		}
+	)
+
+	idx.add(foo)
+	idx.add(all)
+	idx.add(bar)
+	assert.Equal(t, []*policyv1.PodDisruptionBudget{all, foo}, idx.pdbsForPod(pod), "Only PDBs in the same namespace with matching or empty selectors should match")
+	assert.Equal(t, []*policyv1.PodDisruptionBudget{all}, idx.pdbsForPod(&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "default"}}), "Pods without labels should only match empty selectors")
+
+	// update selector
+	fooUpdated := newTestPDB("foo", "default", map[string]string{"app": "baz"})
+	idx.add(fooUpdated)
+	assert.Equal(t, []*policyv1.PodDisruptionBudget{all}, idx.pdbsForPod(pod), "Updated selector should not match pod")
+
+	// delete
+	idx.delete(cache.DeletedFinalStateUnknown{Obj: fooUpdated})
+	_, ok := idx.selectorFor("default", "foo")
+	assert.False(t, ok, "Deleted PDB should be removed from index")
+	_, ok = idx.selectorFor("other", "bar")
+	assert.True(t, ok, "Other PDBs should remain in index")
+}
+
+func TestIndexedLocator(t *testing.T) {
+	t.Parallel()
+
+	var (
+		pdb = newTestPDB("foo", "default", map[string]string{"app": "foo"})
+		pod = &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "foo-1",
+				Namespace: "default",
+				Labels:    map[string]string{"app": "foo"},
+			},
+		}
+		other = &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "bar-1",
+				Namespace: "default",
+				Labels:    map[string]string{"app": "bar"},
+			},
+		}
+	)
+
+	ctx, can := context.WithTimeout(context.Background(), time.Second*10)
+	defer can()
+
+	l, err := NewPDBLocator(ctx, fake.NewSimpleClientset(pdb, pod, other), WithPolicyVersion(policy.V1), WithSelectorIndex(), WithPodInformer())
+	require.NoError(t, err, "Locator should be created without error")
+	defer l.Stop()
+
+	pdbs, err := l.PDBsForPod(ctx, pod)
+	assert.NoError(t, err, "PDBs should be located without error")
+	assert.Equal(t, []*policyv1.PodDisruptionBudget{pdb}, pdbs, "Indexed PDB should match pod")
+
+	pdbs, err = l.PDBsForPod(ctx, other)
+	assert.NoError(t, err, "Pods without PDBs should not return an error")
+	assert.Empty(t, pdbs, "No PDBs should match pod")
+
+	pods, err := l.PodsForPDB(ctx, pdb)
+	assert.NoError(t, err, "Pods should be located without error")
+	assert.Equal(t, []*corev1.Pod{pod}, pods, "Only pods matching selector should be returned")
+}
+
+func newTestPDB(name, namespace string, matchLabels map[string]string) *policyv1.PodDisruptionBudget {
+	return &policyv1.PodDisruptionBudget{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		Spec: policyv1.PodDisruptionBudgetSpec{
+			Selector: &metav1.LabelSelector{MatchLabels: matchLabels},
+		},
+	}
+}
@@ -2,16 +2,152 @@ package locator
 
 import (
 	"context"
+	"errors"
+	"fmt"
 
+	"github.com/fhke/kubectl-draincheck/pkg/policy"
 	corev1 "k8s.io/api/core/v1"
 	policyv1 "k8s.io/api/policy/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	corev1Listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
 )
 
+var (
+	ErrPodsNotCached  = errors.New("pods are not cached, create the locator with WithPodInformer()")
+	ErrOtherNamespace = errors.New("locator does not watch namespace")
+)
+
+// Get the pod disruption budgets that the eviction API would apply to a pod. If no pod
+// disruption budgets match the pod, an empty slice is returned with a nil error.
 func (p *PDBLocator) PDBsForPod(ctx context.Context, pod *corev1.Pod) ([]*policyv1.PodDisruptionBudget, error) {
-	// locate by pod
-	return p.pdbLister.GetPodPodDisruptionBudgets(pod)
+	if err := p.checkNamespace(pod.Namespace); err != nil {
+		return nil, err
+	}
+
+	if p.index != nil {
+		// locate by pre-converted selectors
+		return p.index.pdbsForPod(pod), nil
+	}
+
+	// locate by converting the selectors of pod disruption budgets in namespace
+	all, err := p.listPDBs(pod.Namespace)
+	if err != nil {
+		return nil, fmt.Errorf("error listing pod disruption budgets in namespace %s: %w", pod.Namespace, err)
+	}
+	pdbs := []*policyv1.PodDisruptionBudget{}
+	for _, pdb := range all {
+		selector, err := metav1.LabelSelectorAsSelector(pdb.Spec.Selector)
+		if err != nil {
+			// invalid selectors match no pods
+			continue
+		}
+		if selectorMatches(selector, pod) {
+			pdbs = append(pdbs, pdb)
+		}
+	}
+
+	sortPDBs(pdbs)
+
+	return pdbs, nil
+}
+
+// List cached pod disruption budgets in a namespace, or all namespaces if empty,
+// converting them to policy/v1
+func (p *PDBLocator) listPDBs(namespace string) ([]*policyv1.PodDisruptionBudget, error) {
+	var (
+		pdbs []*policyv1.PodDisruptionBudget
+		err  error
+	)
+
+	appendFn := func(obj interface{}) {
+		if pdb, ok := policy.ToV1(obj); ok {
+			pdbs = append(pdbs, pdb)
+		}
+	}
+	if namespace == "" {
+		err = cache.ListAll(p.pdbIndexer, labels.Everything(), appendFn)
+	} else {
+		err = cache.ListAllByNamespace(p.pdbIndexer, namespace, labels.Everything(), appendFn)
+	}
+
+	return pdbs, err
+}
+
+// Get the cached pods that a pod disruption budget applies to, matching selectors the
+// same way as PDBsForPod. If no pods match, an empty slice is returned with a nil error.
+// Requires the locator to be created with WithPodInformer().
+func (p *PDBLocator) PodsForPDB(ctx context.Context, pdb *policyv1.PodDisruptionBudget) ([]*corev1.Pod, error) {
+	if p.podLister == nil {
+		return nil, ErrPodsNotCached
+	}
+	if err := p.checkNamespace(pdb.Namespace); err != nil {
+		return nil, err
+	}
+
+	// get selector from the index, falling back to converting it
+	selector, ok := labels.Selector(nil), false
+	if p.index != nil {
+		selector, ok = p.index.selectorFor(pdb.Namespace, pdb.Name)
+	}
+	if !ok {
+		var err error
+		if selector, err = metav1.LabelSelectorAsSelector(pdb.Spec.Selector); err != nil {
+			return nil, fmt.Errorf("error parsing selector for pod disruption budget %s/%s: %w", pdb.Namespace, pdb.Name, err)
+		}
+	}
+
+	all, err := p.podLister.Pods(pdb.Namespace).List(labels.Everything())
+	if err != nil {
+		return nil, fmt.Errorf("error listing pods in namespace %s: %w", pdb.Namespace, err)
+	}
+	pods := []*corev1.Pod{}
+	for _, pod := range all {
+		if selectorMatches(selector, pod) {
+			pods = append(pods, pod)
+		}
+	}
+
+	return pods, nil
+}
+
+// Get a lister for cached pods. Returns nil unless the locator was created
+// with WithPodInformer().
+func (p *PDBLocator) PodLister() corev1Listers.PodLister {
+	return p.podLister
+}
+
+// Return an error if the locator only watches a different namespace, rather than
+// silently locating nothing
+func (p *PDBLocator) checkNamespace(namespace string) error {
+	if p.namespace != "" && namespace != p.namespace {
+		return fmt.Errorf("%w %s", ErrOtherNamespace, namespace)
+	}
+
+	return nil
 }
 
+// Stop informers. Stop can be called more than once.
 func (p *PDBLocator) Stop() {
-	p.infStop <- struct{}{}
+	p.stopOnce.Do(func() {
+		close(p.infStop)
+	})
+}
+
+// Check whether informers have synced & are still running
+func (p *PDBLocator) HasSynced() bool {
+	select {
+	case <-p.infStop:
+		return false
+	default:
+	}
+
+	for _, synced := range p.synced {
+		if !synced() {
+			return false
+		}
+	}
+
+	return true
 }
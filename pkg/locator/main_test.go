@@ -0,0 +1,283 @@
+package locator
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fhke/kubectl-draincheck/pkg/policy"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestPDBsForPod(t *testing.T) {
+	t.Parallel()
+
+	var (
+		foo   = newTestPDB("foo", "default", map[string]string{"app": "foo"})
+		empty = newTestPDB("empty", "default", nil)
+	)
+
+	for name, opts := range map[string][]Option{
+		"lister":  {WithPolicyVersion(policy.V1)},
+		"indexed": {WithPolicyVersion(policy.V1), WithSelectorIndex()},
+	} {
+		opts := opts
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx, can := context.WithTimeout(context.Background(), time.Second*10)
+			defer can()
+
+			l, err := NewPDBLocator(ctx, fake.NewSimpleClientset(foo, empty), opts...)
+			require.NoError(t, err, "Locator should be created without error")
+			defer l.Stop()
+
+			pdbs, err := l.PDBsForPod(ctx, newTestPod("default", map[string]string{"app": "foo"}))
+			assert.NoError(t, err, "PDBs should be located without error")
+			assert.Equal(t, []*policyv1.PodDisruptionBudget{empty, foo}, pdbs, "PDBs with matching or empty selectors should be returned")
+
+			for _, pod := range []*corev1.Pod{
+				newTestPod("default", map[string]string{"app": "bar"}),
+				newTestPod("default", nil),
+			} {
+				pdbs, err := l.PDBsForPod(ctx, pod)
+				assert.NoError(t, err, "PDBs should be located without error")
+				assert.Equal(t, []*policyv1.PodDisruptionBudget{empty}, pdbs, "Empty selectors should match all pods in namespace")
+			}
+
+			pdbs, err = l.PDBsForPod(ctx, newTestPod("other", map[string]string{"app": "foo"}))
+			assert.NoError(t, err, "No matching PDBs should not be an error")
+			assert.NotNil(t, pdbs, "No matching PDBs should return an empty slice")
+			assert.Empty(t, pdbs, "PDBs in other namespaces should not match pod")
+		})
+	}
+}
+
+func TestPodsForPDB(t *testing.T) {
+	t.Parallel()
+
+	var (
+		foo   = newTestPDB("foo", "default", map[string]string{"app": "foo"})
+		empty = newTestPDB("empty", "default", nil)
+		none  = &policyv1.PodDisruptionBudget{ObjectMeta: metav1.ObjectMeta{Name: "none", Namespace: "default"}}
+
+		fooPod       = newNamedTestPod("foo-1", "default", map[string]string{"app": "foo"})
+		barPod       = newNamedTestPod("bar-1", "default", map[string]string{"app": "bar"})
+		unlabeledPod = newNamedTestPod("unlabeled", "default", nil)
+		otherPod     = newNamedTestPod("foo-1", "other", map[string]string{"app": "foo"})
+	)
+
+	for name, opts := range map[string][]Option{
+		"lister":  {WithPolicyVersion(policy.V1), WithPodInformer()},
+		"indexed": {WithPolicyVersion(policy.V1), WithPodInformer(), WithSelectorIndex()},
+	} {
+		opts := opts
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx, can := context.WithTimeout(context.Background(), time.Second*10)
+			defer can()
+
+			l, err := NewPDBLocator(ctx, fake.NewSimpleClientset(foo, empty, none, fooPod, barPod, unlabeledPod, otherPod), opts...)
+			require.NoError(t, err, "Locator should be created without error")
+			defer l.Stop()
+
+			pods, err := l.PodsForPDB(ctx, foo)
+			assert.NoError(t, err, "Pods should be located without error")
+			assert.Equal(t, []*corev1.Pod{fooPod}, pods, "Only pods matching selector should be returned")
+
+			pods, err = l.PodsForPDB(ctx, empty)
+			assert.NoError(t, err, "Pods should be located without error")
+			assert.ElementsMatch(t, []*corev1.Pod{fooPod, barPod, unlabeledPod}, pods, "Empty selectors should match all pods in namespace")
+
+			pods, err = l.PodsForPDB(ctx, none)
+			assert.NoError(t, err, "No matching pods should not be an error")
+			assert.NotNil(t, pods, "No matching pods should return an empty slice")
+			assert.Empty(t, pods, "Nil selectors should match no pods")
+
+			// both directions should agree
+			for _, pdb := range []*policyv1.PodDisruptionBudget{foo, empty, none} {
+				pods, err := l.PodsForPDB(ctx, pdb)
+				require.NoError(t, err, "Pods should be located without error")
+				for _, pod := range []*corev1.Pod{fooPod, barPod, unlabeledPod} {
+					pdbs, err := l.PDBsForPod(ctx, pod)
+					require.NoError(t, err, "PDBs should be located without error")
+					assert.Equal(t, containsPod(pods, pod), containsPDB(pdbs, pdb), "PodsForPDB and PDBsForPod should agree for %s & %s", pdb.Name, pod.Name)
+				}
+			}
+		})
+	}
+}
+
+func TestNamespacedLocator(t *testing.T) {
+	t.Parallel()
+
+	var (
+		team  = newTestPDB("team", "team", map[string]string{"app": "foo"})
+		other = newTestPDB("other", "other", map[string]string{"app": "foo"})
+		pod   = newNamedTestPod("foo-1", "team", map[string]string{"app": "foo"})
+	)
+
+	// reject cluster-wide lists & watches, as the API server does for users with
+	// namespace-scoped permissions
+	newClientset := func() (*fake.Clientset, func() []string) {
+		var (
+			mu       sync.Mutex
+			rejected []string
+		)
+		reject := func(action k8stesting.Action) error {
+			if action.GetNamespace() != "" {
+				return nil
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			rejected = append(rejected, action.GetVerb()+" "+action.GetResource().Resource)
+			gr := schema.GroupResource{Group: action.GetResource().Group, Resource: action.GetResource().Resource}
+			return kerrors.NewForbidden(gr, "", errors.New("cluster-wide access is not allowed"))
+		}
+
+		cs := fake.NewSimpleClientset(team, other, pod)
+		cs.PrependReactor("list", "*", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			err := reject(action)
+			return err != nil, nil, err
+		})
+		cs.PrependWatchReactor("*", func(action k8stesting.Action) (bool, watch.Interface, error) {
+			err := reject(action)
+			return err != nil, nil, err
+		})
+
+		return cs, func() []string {
+			mu.Lock()
+			defer mu.Unlock()
+			return append([]string{}, rejected...)
+		}
+	}
+
+	ctx, can := context.WithTimeout(context.Background(), time.Second*10)
+	defer can()
+
+	cs, rejected := newClientset()
+	l, err := NewPDBLocator(ctx, cs, WithPolicyVersion(policy.V1), WithNamespace("team"), WithSelectorIndex(), WithPodInformer())
+	require.NoError(t, err, "Namespaced locator should sync without cluster-wide access")
+	defer l.Stop()
+	assert.True(t, l.HasSynced(), "Namespaced locator should have synced")
+	assert.Empty(t, rejected(), "Namespaced locator should not list or watch across namespaces")
+
+	pdbs, err := l.PDBsForPod(ctx, pod)
+	assert.NoError(t, err, "PDBs should be located without error")
+	assert.Equal(t, []*policyv1.PodDisruptionBudget{team}, pdbs, "Only PDBs in the namespace should be located")
+	pods, err := l.PodsForPDB(ctx, team)
+	assert.NoError(t, err, "Pods should be located without error")
+	assert.Equal(t, []*corev1.Pod{pod}, pods, "Pods in the namespace should be cached")
+
+	// without a namespace, the same clientset is rejected
+	ctx2, can2 := context.WithTimeout(context.Background(), time.Second)
+	defer can2()
+	cs, rejected = newClientset()
+	_, err = NewPDBLocator(ctx2, cs, WithPolicyVersion(policy.V1))
+	assert.Error(t, err, "Cluster-wide locator should not sync without cluster-wide access")
+	assert.NotEmpty(t, rejected(), "Cluster-wide locator should list across namespaces")
+}
+
+func TestLocatorLifecycle(t *testing.T) {
+	t.Parallel()
+
+	ctx, can := context.WithTimeout(context.Background(), time.Second*10)
+	defer can()
+
+	l, err := NewPDBLocator(ctx, fake.NewSimpleClientset(), WithPolicyVersion(policy.V1), WithPodInformer())
+	require.NoError(t, err, "Locator should be created without error")
+	assert.True(t, l.HasSynced(), "Locator should have synced after creation")
+
+	l.Stop()
+	assert.False(t, l.HasSynced(), "Stopped locator should not report synced")
+	assert.NotPanics(t, l.Stop, "Stop should be idempotent")
+}
+
+func TestV1beta1Locator(t *testing.T) {
+	t.Parallel()
+
+	pdb := &policyv1beta1.PodDisruptionBudget{
+		ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "default"},
+		Spec: policyv1beta1.PodDisruptionBudgetSpec{
+			Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "foo"}},
+		},
+	}
+	empty := &policyv1beta1.PodDisruptionBudget{
+		ObjectMeta: metav1.ObjectMeta{Name: "empty", Namespace: "default"},
+		Spec: policyv1beta1.PodDisruptionBudgetSpec{
+			Selector: &metav1.LabelSelector{},
+		},
+	}
+
+	// only serve policy/v1beta1
+	cs := fake.NewSimpleClientset(pdb, empty)
+	cs.Resources = []*metav1.APIResourceList{{
+		GroupVersion: "policy/v1beta1",
+		APIResources: []metav1.APIResource{{Name: "poddisruptionbudgets"}},
+	}}
+
+	ctx, can := context.WithTimeout(context.Background(), time.Second*10)
+	defer can()
+
+	for name, opts := range map[string][]Option{
+		"lister":  nil,
+		"indexed": {WithSelectorIndex()},
+	} {
+		l, err := NewPDBLocator(ctx, cs, opts...)
+		require.NoError(t, err, "Locator should be created without error (%s)", name)
+		defer l.Stop()
+
+		pdbs, err := l.PDBsForPod(ctx, newTestPod("default", map[string]string{"app": "foo"}))
+		assert.NoError(t, err, "PDBs should be located without error (%s)", name)
+		if assert.Len(t, pdbs, 1, "Only the PDB with a matching selector should be returned (%s)", name) {
+			assert.Equal(t, "foo", pdbs[0].Name)
+			assert.Equal(t, policyv1.SchemeGroupVersion.String(), pdbs[0].APIVersion, "PDB should be converted to policy/v1 (%s)", name)
+		}
+	}
+}
+
+func newTestPod(namespace string, podLabels map[string]string) *corev1.Pod {
+	return newNamedTestPod("pod", namespace, podLabels)
+}
+
+func newNamedTestPod(name, namespace string, podLabels map[string]string) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			Labels:    podLabels,
+		},
+	}
+}
+
+func containsPod(pods []*corev1.Pod, pod *corev1.Pod) bool {
+	for _, p := range pods {
+		if p.Namespace == pod.Namespace && p.Name == pod.Name {
+			return true
+		}
+	}
+	return false
+}
+
+func containsPDB(pdbs []*policyv1.PodDisruptionBudget, pdb *policyv1.PodDisruptionBudget) bool {
+	for _, p := range pdbs {
+		if p.Namespace == pdb.Namespace && p.Name == pdb.Name {
+			return true
+		}
+	}
+	return false
+}
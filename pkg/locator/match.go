@@ -0,0 +1,22 @@
+package locator
+
+import (
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// Check whether a pod disruption budget selector matches a pod. Like the policy/v1
+// eviction API, nil (invalid) selectors match no pods & empty selectors match all pods.
+func selectorMatches(selector labels.Selector, pod *corev1.Pod) bool {
+	return selector != nil && selector.Matches(labels.Set(pod.Labels))
+}
+
+// sort pod disruption budgets by name
+func sortPDBs(pdbs []*policyv1.PodDisruptionBudget) {
+	sort.Slice(pdbs, func(i, j int) bool {
+		return pdbs[i].Name < pdbs[j].Name
+	})
+}
@@ -2,27 +2,124 @@ package locator
 
 import (
 	"context"
+	"fmt"
 
+	"github.com/fhke/kubectl-draincheck/pkg/policy"
 	"k8s.io/client-go/informers"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
 )
 
-func NewPDBLocator(ctx context.Context, k kubernetes.Interface) (*PDBLocator, error) {
-	// create shared informer factory & pdb lister
-	infFactory := informers.NewSharedInformerFactory(k, 0)
-	pdbLister := infFactory.Policy().V1().PodDisruptionBudgets().Lister()
+func NewPDBLocator(ctx context.Context, k kubernetes.Interface, opts ...Option) (*PDBLocator, error) {
+	// apply options
+	pdbl := &PDBLocator{}
+	for _, opt := range opts {
+		opt(pdbl)
+	}
+
+	// discover policy API version, if not set
+	if pdbl.policyVersion == "" {
+		v, err := policy.Version(k.Discovery())
+		if err != nil {
+			return nil, fmt.Errorf("error discovering policy API version: %w", err)
+		}
+		pdbl.policyVersion = v
+	}
+
+	// create shared informer factory & pdb informer
+	infFactory := informers.NewSharedInformerFactoryWithOptions(k, 0, informers.WithNamespace(pdbl.namespace))
+	pdbl.infFactory = infFactory
+	var pdbInformer cache.SharedIndexInformer
+	switch pdbl.policyVersion {
+	case policy.V1:
+		pdbInformer = infFactory.Policy().V1().PodDisruptionBudgets().Informer()
+	case policy.V1beta1:
+		pdbInformer = infFactory.Policy().V1beta1().PodDisruptionBudgets().Informer()
+	default:
+		return nil, fmt.Errorf("unsupported policy API version %s", pdbl.policyVersion)
+	}
+	pdbl.pdbIndexer = pdbInformer.GetIndexer()
+
+	pdbl.addInformer(pdbInformer, "poddisruptionbudgets")
+
+	// index selectors, if enabled. Handlers must be added before informers are started.
+	if pdbl.indexPDBs {
+		pdbl.index = newSelectorIndex()
+		pdbInformer.AddEventHandler(pdbl.index.handlers())
+	}
+
+	// create pod lister, if pods are cached
+	if pdbl.cachePods {
+		podInformer := infFactory.Core().V1().Pods()
+		pdbl.podLister = podInformer.Lister()
+		pdbl.addInformer(podInformer.Informer(), "pods")
+	}
 
 	// start informers
-	stopCh := make(chan struct{}, 1)
-	infFactory.Start(stopCh)
+	pdbl.infStop = make(chan struct{})
+	infFactory.Start(pdbl.infStop)
 
-	// create locator
-	pdbl := &PDBLocator{
-		infFactory: infFactory,
-		infStop:    stopCh,
-		pdbLister:  pdbLister,
+	// wait for cache sync, stopping informers if they don't sync
+	if err := pdbl.waitForCacheSync(ctx); err != nil {
+		pdbl.Stop()
+		return nil, err
 	}
 
-	// wait for cache sync, then return locator
-	return pdbl, pdbl.waitForCacheSync(ctx)
+	return pdbl, nil
+}
+
+// Track an informer's sync status & set its watch error handler. Must be called
+// before informers are started.
+func (p *PDBLocator) addInformer(inf cache.SharedIndexInformer, resource string) {
+	p.synced = append(p.synced, inf.HasSynced)
+
+	if p.onWatchError != nil {
+		_ = inf.SetWatchErrorHandler(func(r *cache.Reflector, err error) {
+			// keep the default logging, as well as calling the handler
+			cache.DefaultWatchErrorHandler(r, err)
+			p.onWatchError(resource, err)
+		})
+	}
+}
+
+// Cache pods alongside pod disruption budgets, so that pods can be listed from
+// the cache with PodLister()
+func WithPodInformer() Option {
+	return func(p *PDBLocator) {
+		p.cachePods = true
+	}
+}
+
+// Index pod disruption budgets by namespace & converted selector, updating the index
+// as pod disruption budgets change. Lookups no longer convert every selector in the
+// namespace for each pod.
+func WithSelectorIndex() Option {
+	return func(p *PDBLocator) {
+		p.indexPDBs = true
+	}
+}
+
+// Call a function when an informer's watch fails, e.g. because the API server is
+// unreachable or permissions were revoked. The informer keeps retrying the watch,
+// but cached pod disruption budgets may be stale until it recovers.
+func WithWatchErrorHandler(fn func(resource string, err error)) Option {
+	return func(p *PDBLocator) {
+		p.onWatchError = fn
+	}
+}
+
+// Only watch pod disruption budgets (& pods, if cached) in a namespace. This only needs
+// namespace-scoped permissions, but pods in other namespaces cannot be located.
+func WithNamespace(namespace string) Option {
+	return func(p *PDBLocator) {
+		p.namespace = namespace
+	}
+}
+
+// Set the policy API version to watch pod disruption budgets with, instead of
+// discovering it. Pod disruption budgets are always returned as policy/v1.
+func WithPolicyVersion(version string) Option {
+	return func(p *PDBLocator) {
+		p.policyVersion = version
+	}
 }
@@ -1,8 +1,65 @@
 package locator
 
-import "context"
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+// interval for polling the selector index while it catches up with the informer
+const indexPollInterval = 50 * time.Millisecond
+
+var ErrNotSynced = errors.New("informer caches did not sync")
 
 func (p *PDBLocator) waitForCacheSync(ctx context.Context) error {
-	p.infFactory.WaitForCacheSync(ctx.Done())
-	return ctx.Err()
+	// get informers that did not sync
+	var failed []string
+	for typ, ok := range p.infFactory.WaitForCacheSync(ctx.Done()) {
+		if !ok {
+			failed = append(failed, typeName(typ))
+		}
+	}
+	if len(failed) > 0 {
+		sort.Strings(failed)
+		return fmt.Errorf("%w: %s: %v", ErrNotSynced, strings.Join(failed, ", "), ctx.Err())
+	}
+
+	if p.index != nil {
+		if err := p.waitForIndex(ctx); err != nil {
+			return fmt.Errorf("%w: PodDisruptionBudget index: %v", ErrNotSynced, err)
+		}
+	}
+
+	return nil
+}
+
+// Event handlers run asynchronously, so the index may lag behind the synced cache.
+// Wait until every cached pod disruption budget has been indexed.
+func (p *PDBLocator) waitForIndex(ctx context.Context) error {
+	return wait.PollImmediateUntilWithContext(ctx, indexPollInterval, func(context.Context) (bool, error) {
+		pdbs, err := p.listPDBs("")
+		if err != nil {
+			return false, err
+		}
+		for _, pdb := range pdbs {
+			if !p.index.contains(pdb) {
+				return false, nil
+			}
+		}
+		return true, nil
+	})
+}
+
+// get the name of an informer's object type, e.g. PodDisruptionBudget
+func typeName(typ reflect.Type) string {
+	if typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	return typ.Name()
 }
@@ -1,12 +1,31 @@
 package locator
 
 import (
+	"sync"
+
 	"k8s.io/client-go/informers"
-	policyv1Listers "k8s.io/client-go/listers/policy/v1"
+	corev1Listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
 )
 
-type PDBLocator struct {
-	infFactory informers.SharedInformerFactory
-	infStop    chan struct{}
-	pdbLister  policyv1Listers.PodDisruptionBudgetLister
-}
+type (
+	PDBLocator struct {
+		infFactory informers.SharedInformerFactory
+		infStop    chan struct{}
+		stopOnce   sync.Once
+		synced     []cache.InformerSynced  // sync status of each informer
+		pdbIndexer cache.Indexer           // cached policy/v1 or policy/v1beta1 pod disruption budgets
+		podLister  corev1Listers.PodLister // nil unless pods are cached
+		cachePods  bool                    // start a pod informer
+		index      *selectorIndex          // nil unless selectors are indexed
+		indexPDBs  bool                    // index pod disruption budget selectors
+		namespace  string                  // namespace to watch, or all namespaces if empty
+		// policy API version to watch pod disruption budgets with
+		policyVersion string
+		// called when an informer's watch fails
+		onWatchError func(resource string, err error)
+	}
+
+	// Functional option for configuring a PDBLocator
+	Option func(*PDBLocator)
+)
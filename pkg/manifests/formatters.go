@@ -0,0 +1,38 @@
+package manifests
+
+import (
+	"bytes"
+	"encoding/json"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/yaml"
+)
+
+// Convert manifests to a multi-document YAML stream
+func YAML(objs []runtime.Object) ([]byte, error) {
+	var buf bytes.Buffer
+
+	for _, obj := range objs {
+		data, err := yaml.Marshal(obj)
+		if err != nil {
+			return nil, err
+		}
+		buf.WriteString("---\n")
+		buf.Write(data)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// Convert manifests to a JSON List
+func JSON(objs []runtime.Object) ([]byte, error) {
+	list := &metav1.List{
+		TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "List"},
+	}
+	for _, obj := range objs {
+		list.Items = append(list.Items, runtime.RawExtension{Object: obj})
+	}
+
+	return json.MarshalIndent(list, "", "    ")
+}
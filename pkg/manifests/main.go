@@ -0,0 +1,212 @@
+package manifests
+
+import (
+	"errors"
+
+	"github.com/fhke/kubectl-draincheck/pkg/auth"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+const (
+	DefaultName      = "draincheck"
+	DefaultNamespace = "draincheck"
+	DefaultImage     = "quay.io/fhke97/kubectl-draincheck:latest"
+	DefaultSchedule  = "0 * * * *"
+)
+
+var ErrClusterScopedRules = errors.New("cannot grant permissions for cluster-scoped resources in a namespace")
+
+// Generate a service account, RBAC rules & cron job for running draincheck in a
+// cluster. A Role & RoleBinding are generated when the scope has a namespace,
+// otherwise a ClusterRole & ClusterRoleBinding are generated.
+func Generate(opts ...Option) ([]runtime.Object, error) {
+	o := &Options{
+		Name:      DefaultName,
+		Namespace: DefaultNamespace,
+		Image:     DefaultImage,
+		Schedule:  DefaultSchedule,
+		Scope: auth.Scope{
+			Evict:        true,
+			DrainFilters: true,
+		},
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	rules := auth.Requirements(o.Scope)
+	if o.Scope.Namespace != "" && rules.ClusterScoped() {
+		return nil, ErrClusterScopedRules
+	}
+
+	return []runtime.Object{
+		o.serviceAccount(),
+		o.role(rules),
+		o.roleBinding(),
+		o.cronJob(),
+	}, nil
+}
+
+func (o *Options) serviceAccount() *corev1.ServiceAccount {
+	return &corev1.ServiceAccount{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "ServiceAccount"},
+		ObjectMeta: o.objectMeta(o.Namespace),
+	}
+}
+
+// Get a ClusterRole, or a Role if the scope has a namespace
+func (o *Options) role(rules auth.Rules) runtime.Object {
+	if o.Scope.Namespace == "" {
+		return &rbacv1.ClusterRole{
+			TypeMeta:   metav1.TypeMeta{APIVersion: rbacv1.SchemeGroupVersion.String(), Kind: "ClusterRole"},
+			ObjectMeta: o.objectMeta(""),
+			Rules:      rules.PolicyRules(),
+		}
+	}
+
+	return &rbacv1.Role{
+		TypeMeta:   metav1.TypeMeta{APIVersion: rbacv1.SchemeGroupVersion.String(), Kind: "Role"},
+		ObjectMeta: o.objectMeta(o.Scope.Namespace),
+		Rules:      rules.PolicyRules(),
+	}
+}
+
+// Get a ClusterRoleBinding, or a RoleBinding if the scope has a namespace
+func (o *Options) roleBinding() runtime.Object {
+	subjects := []rbacv1.Subject{{
+		Kind:      rbacv1.ServiceAccountKind,
+		Name:      o.Name,
+		Namespace: o.Namespace,
+	}}
+
+	if o.Scope.Namespace == "" {
+		return &rbacv1.ClusterRoleBinding{
+			TypeMeta:   metav1.TypeMeta{APIVersion: rbacv1.SchemeGroupVersion.String(), Kind: "ClusterRoleBinding"},
+			ObjectMeta: o.objectMeta(""),
+			RoleRef:    rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "ClusterRole", Name: o.Name},
+			Subjects:   subjects,
+		}
+	}
+
+	return &rbacv1.RoleBinding{
+		TypeMeta:   metav1.TypeMeta{APIVersion: rbacv1.SchemeGroupVersion.String(), Kind: "RoleBinding"},
+		ObjectMeta: o.objectMeta(o.Scope.Namespace),
+		RoleRef:    rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "Role", Name: o.Name},
+		Subjects:   subjects,
+	}
+}
+
+func (o *Options) cronJob() *batchv1.CronJob {
+	var (
+		nonRoot      = true
+		noEscalation = false
+		nobody       = int64(65534)
+	)
+
+	return &batchv1.CronJob{
+		TypeMeta:   metav1.TypeMeta{APIVersion: batchv1.SchemeGroupVersion.String(), Kind: "CronJob"},
+		ObjectMeta: o.objectMeta(o.Namespace),
+		Spec: batchv1.CronJobSpec{
+			Schedule:          o.Schedule,
+			ConcurrencyPolicy: batchv1.ForbidConcurrent,
+			JobTemplate: batchv1.JobTemplateSpec{
+				Spec: batchv1.JobSpec{
+					Template: corev1.PodTemplateSpec{
+						ObjectMeta: metav1.ObjectMeta{Labels: o.labels()},
+						Spec: corev1.PodSpec{
+							ServiceAccountName: o.Name,
+							RestartPolicy:      corev1.RestartPolicyNever,
+							Containers: []corev1.Container{{
+								Name:  "draincheck",
+								Image: o.Image,
+								Args:  o.args(),
+								SecurityContext: &corev1.SecurityContext{
+									RunAsNonRoot:             &nonRoot,
+									RunAsUser:                &nobody,
+									ReadOnlyRootFilesystem:   &nonRoot,
+									AllowPrivilegeEscalation: &noEscalation,
+								},
+							}},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// Get draincheck arguments matching the scope
+func (o *Options) args() []string {
+	var args []string
+
+	if o.Scope.Namespace != "" {
+		args = append(args, "--namespace", o.Scope.Namespace)
+	} else {
+		args = append(args, "--all-namespaces")
+	}
+	if !o.Scope.Evict {
+		args = append(args, "--offline")
+	}
+
+	return append(args, "--output", "json")
+}
+
+func (o *Options) objectMeta(namespace string) metav1.ObjectMeta {
+	return metav1.ObjectMeta{
+		Name:      o.Name,
+		Namespace: namespace,
+		Labels:    o.labels(),
+	}
+}
+
+func (o *Options) labels() map[string]string {
+	return map[string]string{
+		"app.kubernetes.io/name": o.Name,
+	}
+}
+
+// Set the name of all resources
+func WithName(name string) Option {
+	return func(o *Options) {
+		o.Name = name
+	}
+}
+
+// Set the namespace to create the service account & cron job in
+func WithNamespace(namespace string) Option {
+	return func(o *Options) {
+		o.Namespace = namespace
+	}
+}
+
+// Set the draincheck image
+func WithImage(image string) Option {
+	return func(o *Options) {
+		o.Image = image
+	}
+}
+
+// Set the cron schedule
+func WithSchedule(schedule string) Option {
+	return func(o *Options) {
+		o.Schedule = schedule
+	}
+}
+
+// Only grant permissions to check pods in a namespace, with a Role instead of a ClusterRole
+func WithTargetNamespace(namespace string) Option {
+	return func(o *Options) {
+		o.Scope.Namespace = namespace
+	}
+}
+
+// Evaluate pod disruption budgets offline, without permission to create evictions
+func WithOffline() Option {
+	return func(o *Options) {
+		o.Scope.Evict = false
+	}
+}
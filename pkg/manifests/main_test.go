@@ -0,0 +1,42 @@
+package manifests
+
+import (
+	"testing"
+
+	"github.com/fhke/kubectl-draincheck/pkg/auth"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	batchv1 "k8s.io/api/batch/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+)
+
+func TestGenerate(t *testing.T) {
+	t.Parallel()
+
+	// cluster-wide
+	objs, err := Generate()
+	require.NoError(t, err, "Generating manifests should not return error")
+	require.Len(t, objs, 4)
+	if role, ok := objs[1].(*rbacv1.ClusterRole); assert.True(t, ok, "ClusterRole should be generated for all namespaces") {
+		assert.Equal(t, auth.Requirements(auth.Scope{Evict: true, DrainFilters: true}).PolicyRules(), role.Rules, "Rules should match requirements")
+	}
+	assert.IsType(t, &rbacv1.ClusterRoleBinding{}, objs[2])
+	if cj, ok := objs[3].(*batchv1.CronJob); assert.True(t, ok, "CronJob should be generated") {
+		assert.Equal(t, DefaultImage, cj.Spec.JobTemplate.Spec.Template.Spec.Containers[0].Image)
+		assert.Contains(t, cj.Spec.JobTemplate.Spec.Template.Spec.Containers[0].Args, "--all-namespaces")
+	}
+
+	// namespace-scoped & offline
+	objs, err = Generate(WithTargetNamespace("foo"), WithOffline())
+	require.NoError(t, err, "Generating manifests should not return error")
+	if role, ok := objs[1].(*rbacv1.Role); assert.True(t, ok, "Role should be generated for a namespace") {
+		assert.Equal(t, "foo", role.Namespace)
+		for _, rule := range role.Rules {
+			assert.NotContains(t, rule.Resources, "pods/eviction", "Offline checks should not be allowed to create evictions")
+		}
+	}
+	assert.IsType(t, &rbacv1.RoleBinding{}, objs[2])
+
+	_, err = Generate(WithTargetNamespace("foo"), func(o *Options) { o.Scope.ListNodes = true })
+	assert.ErrorIs(t, err, ErrClusterScopedRules, "Namespaced roles cannot grant access to nodes")
+}
@@ -0,0 +1,17 @@
+package manifests
+
+import "github.com/fhke/kubectl-draincheck/pkg/auth"
+
+type (
+	// Options for generating manifests
+	Options struct {
+		Name      string     // name of all resources
+		Namespace string     // namespace to create the service account & cron job in
+		Image     string     // draincheck image
+		Schedule  string     // cron schedule
+		Scope     auth.Scope // permissions to grant. Scope.Namespace is empty for all namespaces
+	}
+
+	// Functional option for generating manifests
+	Option func(*Options)
+)
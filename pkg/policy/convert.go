@@ -0,0 +1,60 @@
+package policy
+
+import (
+	policyv1 "k8s.io/api/policy/v1"
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Label key used to convert empty policy/v1beta1 selectors, which match no pods, to
+// policy/v1 selectors, which match all pods. This is the same key that the API server
+// uses for the conversion.
+const EmptySelectorMatchKey = "pdb.kubernetes.io/deprecated-v1beta1-empty-selector-match"
+
+// Get a pod disruption budget from an informer object as policy/v1, converting it
+// from policy/v1beta1 if needed
+func ToV1(obj interface{}) (*policyv1.PodDisruptionBudget, bool) {
+	switch pdb := obj.(type) {
+	case *policyv1.PodDisruptionBudget:
+		return pdb, true
+	case *policyv1beta1.PodDisruptionBudget:
+		return ConvertV1beta1(pdb), true
+	default:
+		return nil, false
+	}
+}
+
+// Convert a policy/v1beta1 pod disruption budget to policy/v1
+func ConvertV1beta1(in *policyv1beta1.PodDisruptionBudget) *policyv1.PodDisruptionBudget {
+	in = in.DeepCopy()
+
+	out := &policyv1.PodDisruptionBudget{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "PodDisruptionBudget",
+			APIVersion: policyv1.SchemeGroupVersion.String(),
+		},
+		ObjectMeta: in.ObjectMeta,
+		Spec: policyv1.PodDisruptionBudgetSpec{
+			MinAvailable:   in.Spec.MinAvailable,
+			Selector:       in.Spec.Selector,
+			MaxUnavailable: in.Spec.MaxUnavailable,
+		},
+		Status: policyv1.PodDisruptionBudgetStatus(in.Status),
+	}
+	if in.Spec.UnhealthyPodEvictionPolicy != nil {
+		policy := policyv1.UnhealthyPodEvictionPolicyType(*in.Spec.UnhealthyPodEvictionPolicy)
+		out.Spec.UnhealthyPodEvictionPolicy = &policy
+	}
+
+	// an empty selector matches no pods in policy/v1beta1, so keep it matching no pods
+	if sel := out.Spec.Selector; sel != nil && len(sel.MatchLabels) == 0 && len(sel.MatchExpressions) == 0 {
+		out.Spec.Selector = &metav1.LabelSelector{
+			MatchExpressions: []metav1.LabelSelectorRequirement{{
+				Key:      EmptySelectorMatchKey,
+				Operator: metav1.LabelSelectorOpExists,
+			}},
+		}
+	}
+
+	return out
+}
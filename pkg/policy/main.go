@@ -0,0 +1,41 @@
+package policy
+
+import (
+	"errors"
+	"fmt"
+
+	policyv1 "k8s.io/api/policy/v1"
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+)
+
+const (
+	V1      = "v1"      // policy/v1, served by Kubernetes 1.21+
+	V1beta1 = "v1beta1" // policy/v1beta1, served by Kubernetes up to 1.24
+)
+
+var ErrNotServed = errors.New("pod disruption budgets are not served by policy/v1 or policy/v1beta1")
+
+// Discover the preferred policy API version served by the API server. policy/v1 is
+// preferred, falling back to policy/v1beta1 for clusters older than 1.21.
+func Version(d discovery.DiscoveryInterface) (string, error) {
+	for _, gv := range []schema.GroupVersion{policyv1.SchemeGroupVersion, policyv1beta1.SchemeGroupVersion} {
+		resources, err := d.ServerResourcesForGroupVersion(gv.String())
+		if kerrors.IsNotFound(err) {
+			// group version is not served
+			continue
+		} else if err != nil {
+			return "", fmt.Errorf("error discovering resources for %s: %w", gv, err)
+		}
+
+		for _, r := range resources.APIResources {
+			if r.Name == "poddisruptionbudgets" {
+				return gv.Version, nil
+			}
+		}
+	}
+
+	return "", ErrNotServed
+}
@@ -0,0 +1,92 @@
+package policy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	policyv1 "k8s.io/api/policy/v1"
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestVersion(t *testing.T) {
+	t.Parallel()
+
+	pdbResource := []metav1.APIResource{{Name: "poddisruptionbudgets"}}
+
+	for name, tc := range map[string]struct {
+		resources []*metav1.APIResourceList
+		expected  string
+	}{
+		"v1": {
+			resources: []*metav1.APIResourceList{
+				{GroupVersion: "policy/v1", APIResources: pdbResource},
+				{GroupVersion: "policy/v1beta1", APIResources: pdbResource},
+			},
+			expected: V1,
+		},
+		"v1beta1": {
+			resources: []*metav1.APIResourceList{
+				{GroupVersion: "policy/v1beta1", APIResources: pdbResource},
+			},
+			expected: V1beta1,
+		},
+	} {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			d := fake.NewSimpleClientset().Discovery().(*fakediscovery.FakeDiscovery)
+			d.Resources = tc.resources
+
+			v, err := Version(d)
+			require.NoError(t, err, "Discovery should not return error")
+			assert.Equal(t, tc.expected, v, "Unexpected policy version")
+		})
+	}
+
+	_, err := Version(fake.NewSimpleClientset().Discovery())
+	assert.ErrorIs(t, err, ErrNotServed, "Discovery should return error if no policy version is served")
+}
+
+func TestConvertV1beta1(t *testing.T) {
+	t.Parallel()
+
+	minAvailable := intstr.FromInt(1)
+	in := &policyv1beta1.PodDisruptionBudget{
+		ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "default"},
+		Spec: policyv1beta1.PodDisruptionBudgetSpec{
+			MinAvailable: &minAvailable,
+			Selector:     &metav1.LabelSelector{MatchLabels: map[string]string{"app": "foo"}},
+		},
+		Status: policyv1beta1.PodDisruptionBudgetStatus{DisruptionsAllowed: 2, CurrentHealthy: 3},
+	}
+
+	out, ok := ToV1(in)
+	require.True(t, ok, "policy/v1beta1 PDB should be converted")
+	assert.Equal(t, "foo", out.Name)
+	assert.Equal(t, &minAvailable, out.Spec.MinAvailable)
+	assert.Equal(t, in.Spec.Selector, out.Spec.Selector, "Non-empty selectors should be unchanged")
+	assert.Equal(t, int32(2), out.Status.DisruptionsAllowed)
+
+	in.Spec.Selector = &metav1.LabelSelector{}
+	out = ConvertV1beta1(in)
+	assert.Equal(t, []metav1.LabelSelectorRequirement{{Key: EmptySelectorMatchKey, Operator: metav1.LabelSelectorOpExists}}, out.Spec.Selector.MatchExpressions, "Empty selectors should be converted to match no pods")
+	assert.Empty(t, in.Spec.Selector.MatchExpressions, "Input should not be modified")
+
+	alwaysAllow := policyv1beta1.AlwaysAllow
+	in.Spec.UnhealthyPodEvictionPolicy = &alwaysAllow
+	out = ConvertV1beta1(in)
+	if assert.NotNil(t, out.Spec.UnhealthyPodEvictionPolicy, "Unhealthy pod eviction policy should be converted") {
+		assert.Equal(t, policyv1.AlwaysAllow, *out.Spec.UnhealthyPodEvictionPolicy)
+	}
+
+	_, ok = ToV1(&policyv1.PodDisruptionBudget{})
+	assert.True(t, ok, "policy/v1 PDB should be returned as is")
+	_, ok = ToV1("foo")
+	assert.False(t, ok, "Other types should not be converted")
+}
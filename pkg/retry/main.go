@@ -0,0 +1,71 @@
+package retry
+
+import (
+	"context"
+	"time"
+
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	utilnet "k8s.io/apimachinery/pkg/util/net"
+)
+
+// Call fn until it succeeds, returns an error that is not retriable, runs out of
+// retries, or ctx is done. The last error returned by fn is returned. If the API
+// server asks clients to wait before retrying, e.g. with a Retry-After header, the
+// delay before the next retry is at least as long as the server asks for.
+func Do(ctx context.Context, b Backoff, retriable func(error) bool, fn func() error) error {
+	b = b.withDefaults()
+	delay := b.Initial
+
+	for attempt := uint(0); ; attempt++ {
+		err := fn()
+		if err == nil || attempt >= b.Retries || !retriable(err) {
+			return err
+		}
+
+		// wait before retrying
+		wait := delay
+		if seconds, ok := kerrors.SuggestsClientDelay(err); ok {
+			if suggested := time.Duration(seconds) * time.Second; suggested > wait {
+				wait = suggested
+			}
+		}
+
+		t := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return err
+		case <-t.C:
+		}
+
+		// increase delay for next retry
+		delay = time.Duration(float64(delay) * b.Factor)
+		if delay > b.Max {
+			delay = b.Max
+		}
+	}
+}
+
+// Default the factor if it would stop the delay growing, and raise the maximum delay
+// to the initial delay if it is lower, so that retries don't collapse into a tight loop
+func (b Backoff) withDefaults() Backoff {
+	if b.Factor < 1 {
+		b.Factor = DefaultBackoff.Factor
+	}
+	if b.Max < b.Initial {
+		b.Max = b.Initial
+	}
+
+	return b
+}
+
+// Check whether an error from the API server is likely to be transient
+func IsTransient(err error) bool {
+	return kerrors.IsTooManyRequests(err) ||
+		kerrors.IsServerTimeout(err) ||
+		kerrors.IsTimeout(err) ||
+		kerrors.IsServiceUnavailable(err) ||
+		kerrors.IsInternalError(err) ||
+		utilnet.IsConnectionReset(err) ||
+		utilnet.IsProbableEOF(err)
+}
@@ -0,0 +1,75 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestDo(t *testing.T) {
+	t.Parallel()
+
+	b := Backoff{
+		Retries: 3,
+		Initial: time.Millisecond,
+		Max:     time.Millisecond * 5,
+		Factor:  2,
+	}
+
+	// succeeds after transient errors
+	var calls int
+	err := Do(context.TODO(), b, IsTransient, func() error {
+		calls++
+		if calls < 3 {
+			return kerrors.NewTooManyRequests("slow down", 0)
+		}
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, calls)
+
+	// gives up after retries are used up
+	calls = 0
+	err = Do(context.TODO(), b, IsTransient, func() error {
+		calls++
+		return kerrors.NewServiceUnavailable("unavailable")
+	})
+	assert.True(t, kerrors.IsServiceUnavailable(err))
+	assert.Equal(t, 4, calls)
+
+	// doesn't retry errors that aren't retriable
+	calls = 0
+	err = Do(context.TODO(), b, IsTransient, func() error {
+		calls++
+		return kerrors.NewNotFound(schema.GroupResource{Resource: "pods"}, "foo")
+	})
+	assert.True(t, kerrors.IsNotFound(err))
+	assert.Equal(t, 1, calls)
+	assert.False(t, IsTransient(errors.New("foo")))
+}
+
+func TestBackoffWithDefaults(t *testing.T) {
+	t.Parallel()
+
+	b := Backoff{Retries: 3, Initial: time.Millisecond}.withDefaults()
+	assert.Equal(t, DefaultBackoff.Factor, b.Factor, "Zero factor should be defaulted")
+	assert.Equal(t, time.Millisecond, b.Max, "Max should be at least the initial delay")
+
+	b = Backoff{Initial: time.Millisecond, Max: time.Second, Factor: 0.5}.withDefaults()
+	assert.Equal(t, DefaultBackoff.Factor, b.Factor, "Factor less than 1 should be defaulted")
+	assert.Equal(t, time.Second, b.Max, "Valid max should be unchanged")
+
+	assert.Equal(t, DefaultBackoff, DefaultBackoff.withDefaults(), "Valid backoff should be unchanged")
+
+	// retries wait for the initial delay, rather than collapsing to no delay
+	start := time.Now()
+	_ = Do(context.TODO(), Backoff{Retries: 2, Initial: time.Millisecond * 10}, IsTransient, func() error {
+		return kerrors.NewServiceUnavailable("unavailable")
+	})
+	assert.GreaterOrEqual(t, time.Since(start), time.Millisecond*20, "Each retry should wait")
+}
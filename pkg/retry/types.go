@@ -0,0 +1,22 @@
+package retry
+
+import "time"
+
+// Backoff configures retries with exponential backoff
+type Backoff struct {
+	Retries uint          // maximum number of retries
+	Initial time.Duration // delay before the first retry
+	Max     time.Duration // maximum delay between retries, at least Initial
+	Factor  float64       // multiplier for the delay after each retry, defaulted if less than 1
+}
+
+// Default backoff used if none is configured
+var DefaultBackoff = Backoff{
+	Retries: 3,
+	Initial: time.Millisecond * 500,
+	Max:     time.Second * 10,
+	Factor:  2,
+}
+
+// Backoff that never retries
+var NoRetries = Backoff{}